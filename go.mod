@@ -4,14 +4,15 @@ go 1.23
 
 require (
 	github.com/PuerkitoBio/goquery v1.10.0
+	github.com/andybalholm/cascadia v1.3.2
 	github.com/hashicorp/go-multierror v1.1.1
 	github.com/spf13/cobra v1.8.1
+	golang.org/x/net v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/andybalholm/cascadia v1.3.2 // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/net v0.31.0 // indirect
 )
@@ -0,0 +1,44 @@
+package htmltomd
+
+import (
+	"github.com/david-mk-lawrence/htmltomd/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+type configCmd struct {
+}
+
+func init() {
+	c := configCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "work with " + config.FileName + " configuration files",
+	}
+
+	validateCmd := &cobra.Command{
+		Use:   "validate [" + config.FileName + "]",
+		Short: "check a configuration file for errors",
+		Long: `Checks that every option in the configuration file is known and valid, and lists each one
+that is not. If no file is given, then "` + config.FileName + `" in the current directory is checked.`,
+		RunE: c.validate,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(cmd)
+}
+
+func (c *configCmd) validate(cmd *cobra.Command, args []string) error {
+	file := config.FileName
+	if len(args) > 0 {
+		file = args[0]
+	}
+
+	cmd.SilenceUsage = true
+	if _, err := config.Load(file); err != nil {
+		return err
+	}
+	out("%s is valid", file)
+	return nil
+}
@@ -1,50 +1,164 @@
 package htmltomd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/david-mk-lawrence/htmltomd/pkg/config"
 	"github.com/david-mk-lawrence/htmltomd/pkg/converter"
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
 )
 
 type convertCmd struct {
-	outputDir    string
-	outputFormat string
-	inputFormat  string
-	asciiOnly    bool
+	outputDir      string
+	outputDirConf  bool
+	outputFormat   string
+	inputFormat    string
+	asciiOnly      bool
+	frontMatter    string
+	noTitleHeading bool
+	spanFill       string
+	strict         bool
+	passthrough    bool
+	skipChrome     []string
+	extractMain    bool
+	configFile     string
+	conf           *config.Config
+	recursive      bool
+	include        []string
+	exclude        []string
+	inputRoot      string
+	jobs           int
+	failFast       bool
+	toStdout       bool
+	stdinFilename  string
+	stdout         io.Writer
+	stdoutMu       sync.Mutex
+	wroteStdout    bool
+	report         string
+	reportMu       sync.Mutex
+	diagnostics    []fileDiagnostic
+	positions      map[string]int
 }
 
+// fileDiagnostic is a diagnostic about the conversion of a file
+type fileDiagnostic struct {
+	File string `json:"file"`
+	converter.Diagnostic
+}
+
+// stdinArg is the argument used to read the html from stdin
+const stdinArg = "-"
+
+// inputExtensions are the extensions of the files that are converted
+var inputExtensions = []string{".html", ".htm", ".xhtml"}
+
 func init() {
-	c := convertCmd{}
+	rootCmd.AddCommand(newConvertCmd())
+}
+
+// newConvertCmd creates the convert command, with its own options
+func newConvertCmd() *cobra.Command {
+	c := &convertCmd{}
 
 	cmd := &cobra.Command{
-		Use:   "convert [input.html|input_directory]",
+		Use:   "convert [input.html|input_directory|-]",
 		Short: "convert HTML file(s) to markdown",
 		Long: `Input may be specified as either a directory or file. If a directory is given,
-then all ".html" files in the directory will be converted.
+then all ".html", ".htm", and ".xhtml" files in the directory will be converted.
+With --recursive, files in all subdirectories are converted as well.
 
 If an output directory is specified, then the converted markdown files will be placed
-there. Otherwise, a directory will be created called "html_to_md_converted".`,
+there. Otherwise, a directory will be created called "html_to_md_converted".
+Files in subdirectories are placed in the same subdirectories of the output directory.
+
+Files can be filtered with --include and --exclude glob patterns. A pattern without a "/"
+is matched against the name of each file or directory, otherwise it is matched against
+the path relative to the input directory. An excluded directory is not searched.
+
+If the input is "-", then the html is read from stdin and the markdown is written to stdout.
+Use --stdout to write the markdown of input files to stdout as well.
+
+Elements that cannot be converted, such as "dl" or "iframe", are left out of the markdown.
+Use --passthrough to embed their html in the markdown instead, except for embedded content
+and forms, or --strict to fail the file on any content that is left out or loses formatting.`,
 		RunE: c.convert,
 		Args: cobra.ExactArgs(1),
 	}
-	cmd.PersistentFlags().StringVar(&c.inputFormat, "input-format", "html", "source of html file. Can be 'html', 'confluence', or 'google'.")
-	cmd.PersistentFlags().StringVar(&c.outputFormat, "output-format", "md", "style of markdown output. Can be 'hugo' or 'md'.")
+	cmd.PersistentFlags().StringVar(&c.configFile, "config", "", "configuration file. Defaults to "+config.FileName+" in the input directory, if it exists.")
+	cmd.PersistentFlags().StringVar(&c.inputFormat, "input-format", "html", "source of html file. Can be 'html', 'confluence', 'google', or 'auto' to detect the source of each file.")
+	cmd.PersistentFlags().StringVar(&c.outputFormat, "output-format", "md", "style of markdown output. Can be "+converter.QuoteChoices(converter.RendererNames())+".")
 	cmd.PersistentFlags().StringVarP(&c.outputDir, "out", "o", "./html_to_md_converted", "output directory")
 	cmd.PersistentFlags().BoolVar(&c.asciiOnly, "ascii-only", false, "removes all non-ascii characters")
+	cmd.PersistentFlags().StringVar(&c.frontMatter, "front-matter", "", "format of the front matter with the title and metadata. Can be 'yaml', 'toml', 'json', or 'none'. Defaults to 'yaml' for the mdx output format, and 'none' otherwise.")
+	cmd.PersistentFlags().BoolVar(&c.noTitleHeading, "no-title-heading", false, "do not render the title as a header. Useful when the title is in the front matter.")
+	cmd.PersistentFlags().BoolVarP(&c.recursive, "recursive", "r", false, "convert files in all subdirectories of the input directory")
+	cmd.PersistentFlags().StringSliceVar(&c.include, "include", nil, "only convert files matching the glob pattern. May be given more than once.")
+	cmd.PersistentFlags().StringSliceVar(&c.exclude, "exclude", nil, "do not convert files or search directories matching the glob pattern. May be given more than once.")
+	cmd.PersistentFlags().IntVarP(&c.jobs, "jobs", "j", runtime.NumCPU(), "number of files to convert at the same time")
+	cmd.PersistentFlags().BoolVar(&c.failFast, "fail-fast", false, "stop converting files after the first file fails. Files already being converted are finished.")
+	cmd.PersistentFlags().BoolVar(&c.toStdout, "stdout", false, "write the markdown to stdout instead of files in the output directory")
+	cmd.PersistentFlags().StringVar(&c.stdinFilename, "stdin-filename", "stdin.html", "name of the html read from stdin, used in messages, and to name the markdown file if --out or the configuration gives an output directory")
+	cmd.PersistentFlags().StringVar(&c.report, "report", "", "report content that is left out of the markdown, or loses formatting, to stderr. Can be 'text' or 'json'.")
+	cmd.PersistentFlags().StringVar(&c.spanFill, "span-fill", "blank", "how table cells covered by a colspan or rowspan are filled. Can be 'blank' or 'repeat'.")
+	cmd.PersistentFlags().BoolVar(&c.strict, "strict", false, "fail to convert a file with content that is left out of the markdown or loses formatting")
+	cmd.PersistentFlags().BoolVar(&c.passthrough, "passthrough", false, "embed the html of elements that cannot be converted to markdown, instead of leaving them out")
+	cmd.MarkFlagsMutuallyExclusive("strict", "passthrough")
+	cmd.PersistentFlags().BoolVar(&c.extractMain, "extract-main", false, "convert only the main content of the page, leaving out menus, banners, sidebars, and footers")
+	cmd.PersistentFlags().StringSliceVar(&c.skipChrome, "skip-chrome", nil, "leave the page's 'nav', 'header', or 'footer' out of the markdown. May be given more than once.")
 
-	rootCmd.AddCommand(cmd)
+	return cmd
 }
 
 func (c *convertCmd) convert(cmd *cobra.Command, args []string) (err error) {
+	c.stdout = cmd.OutOrStdout()
+	messages = c.stdout
+	if c.toStdout || args[0] == stdinArg {
+		// Only the markdown is written to stdout, so that it can be piped
+		messages = cmd.ErrOrStderr()
+	}
+
+	if err = c.loadConfig(cmd, args[0]); err != nil {
+		return
+	}
+
+	conv, err := c.newDocumentConverter()
+	if err != nil {
+		return
+	}
+	if c.report != "" && c.report != "text" && c.report != "json" {
+		return fmt.Errorf("unknown report format %q. Can be 'text' or 'json'", c.report)
+	}
+	defer func() {
+		if reportErr := c.printReport(cmd.ErrOrStderr()); reportErr != nil && err == nil {
+			err = reportErr
+		}
+	}()
+	for _, pattern := range append(append([]string{}, c.include...), c.exclude...) {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+	}
+
+	if args[0] == stdinArg {
+		cmd.SilenceUsage = true
+		return c.convertStdin(cmd, conv)
+	}
+
 	htmlPath, err := filepath.Abs(args[0])
 	if err != nil {
 		return
@@ -56,49 +170,335 @@ func (c *convertCmd) convert(cmd *cobra.Command, args []string) (err error) {
 		return
 	}
 	outV("Found %d html files", len(htmlFiles))
-
-	if err = os.MkdirAll(c.outputDir, 0755); err != nil {
-		return
+	if c.outputFormat == converter.OutputMDX {
+		c.positions = sidebarPositions(htmlFiles)
 	}
-	outV("Placing markdown files in %s", c.outputDir)
 
-	var wg sync.WaitGroup
-	wgDoneChan := make(chan bool)
-	errChan := make(chan error)
-
-	textCleaner := converter.NewTextCleaner(&converter.TextCleanerConf{AsciiOnly: c.asciiOnly})
-	transformer := converter.NewTransformer(&converter.TransformerConf{Format: &c.outputFormat, TextCleaner: textCleaner})
-	conf := converter.SelectionConverterConfig{
-		Transformer: transformer,
-	}
-	var selConv converter.SelectionConverter
-	if c.inputFormat == "confluence" {
-		selConv = converter.NewConfluenceSelectionConverter(conf)
-	} else if c.inputFormat == "google" {
-		selConv = converter.NewGoogleSelectionConverter(conf)
+	if c.toStdout {
+		// Files are converted one at a time so that they are written to stdout in order
+		c.jobs = 1
 	} else {
-		selConv = converter.NewHTMLSelectionConverter(conf)
+		if err = c.checkOutputFiles(htmlFiles); err != nil {
+			return
+		}
+		if err = os.MkdirAll(c.outputDir, 0755); err != nil {
+			return
+		}
+		outV("Placing markdown files in %s", c.outputDir)
 	}
-	conv := converter.NewDocumentConverter(selConv, &converter.DocumentConverterConf{
-		TextCleaner: textCleaner,
-	})
 
-	for _, htmlFile := range htmlFiles {
+	// Errors from here on are errors converting files, not errors using the command
+	cmd.SilenceUsage = true
+
+	converted, err := c.convertFiles(cmd.Context(), conv, htmlFiles)
+	failed := 0
+	if merr, isMultiErr := err.(*multierror.Error); isMultiErr {
+		failed = len(merr.Errors)
+	}
+	out("Converted %d files: %d succeeded, %d failed, %d skipped", len(htmlFiles), len(converted), failed, len(htmlFiles)-len(converted)-failed)
+
+	if c.outputFormat == converter.OutputMkDocs && !c.toStdout {
+		if navErr := c.writeMkDocsConfig(converted); navErr != nil {
+			err = multierror.Append(err, navErr)
+		}
+	}
+
+	return
+}
+
+// newDocumentConverter creates the DocumentConverter for the input and output formats given by the flags
+func (c *convertCmd) newDocumentConverter(opts ...converter.Option) (*converter.DocumentConverter, error) {
+	spanFill, err := converter.ParseSpanFill(c.spanFill)
+	if err != nil {
+		return nil, err
+	}
+
+	var skipChrome []converter.Chrome
+	for _, name := range c.skipChrome {
+		chrome, err := converter.ParseChrome(name)
+		if err != nil {
+			return nil, err
+		}
+		skipChrome = append(skipChrome, chrome)
+	}
+
+	// The front matter is only set if it is given, so that the output format can choose it otherwise
+	if c.frontMatter != "" {
+		frontMatterFormat, err := markdown.ParseFrontMatterFormat(c.frontMatter)
+		if err != nil {
+			return nil, err
+		}
+		opts = append([]converter.Option{converter.WithFrontMatter(frontMatterFormat)}, opts...)
+	}
+
+	policy := converter.DropPolicy
+	if c.strict {
+		policy = converter.StrictPolicy
+	} else if c.passthrough {
+		policy = converter.PassthroughPolicy
+	}
+
+	if c.conf != nil {
+		confOpts, err := c.conf.Options()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(confOpts, opts...)
+	}
+
+	return converter.NewConverter(append([]converter.Option{
+		converter.WithInputFormat(c.inputFormat),
+		converter.WithOutputFormat(c.outputFormat),
+		converter.WithAsciiOnly(c.asciiOnly),
+		converter.WithTitleHeading(!c.noTitleHeading),
+		converter.WithSpanFill(spanFill),
+		converter.WithElementPolicy(policy),
+		converter.WithSkipChrome(skipChrome...),
+		converter.WithExtractMain(c.extractMain),
+	}, opts...)...)
+}
+
+// loadConfig loads the configuration file given by --config, or found in the directory of the input.
+// Options in the file are used for any flags that are not given.
+func (c *convertCmd) loadConfig(cmd *cobra.Command, input string) error {
+	file := c.configFile
+	if file == "" {
+		dir := input
+		if input == stdinArg {
+			dir = "."
+		} else if info, err := os.Stat(input); err != nil || !info.IsDir() {
+			dir = filepath.Dir(input)
+		}
+
+		var found bool
+		if file, found = config.Find(dir); !found {
+			return nil
+		}
+	}
+
+	conf, err := config.Load(file)
+	if err != nil {
+		return err
+	}
+	outV("Using configuration %s", file)
+	c.conf = conf
+
+	// The output directory is relative to the configuration file, so it is the same from any working directory
+	out := conf.Out
+	if out != "" && !filepath.IsAbs(out) {
+		out = filepath.Join(filepath.Dir(file), out)
+	}
+
+	// Options are set on the command directly, rather than with the flags, so that
+	// a flag is only changed if it is given on the command line
+	flags := cmd.Flags()
+	setString := func(flag string, option *string, value string) {
+		if value != "" && !flags.Changed(flag) {
+			*option = value
+		}
+	}
+	setStrings := func(flag string, option *[]string, value []string) {
+		if len(value) > 0 && !flags.Changed(flag) {
+			*option = value
+		}
+	}
+	setBool := func(flag string, option *bool, value *bool) {
+		if value != nil && !flags.Changed(flag) {
+			*option = *value
+		}
+	}
+
+	setString("input-format", &c.inputFormat, conf.InputFormat)
+	setString("output-format", &c.outputFormat, conf.OutputFormat)
+	if out != "" && !flags.Changed("out") {
+		c.outputDir = out
+		c.outputDirConf = true
+	}
+	setBool("recursive", &c.recursive, conf.Recursive)
+	setStrings("include", &c.include, conf.Include)
+	setStrings("exclude", &c.exclude, conf.Exclude)
+	setBool("ascii-only", &c.asciiOnly, conf.AsciiOnly)
+	setString("front-matter", &c.frontMatter, conf.FrontMatter)
+	setBool("no-title-heading", &c.noTitleHeading, conf.NoTitleHeading)
+	setString("span-fill", &c.spanFill, conf.SpanFill)
+	setBool("extract-main", &c.extractMain, conf.ExtractMain)
+	setStrings("skip-chrome", &c.skipChrome, conf.SkipChrome)
+	if conf.Jobs > 0 && !flags.Changed("jobs") {
+		c.jobs = conf.Jobs
+	}
+	// Strict and passthrough cannot both be set, so a flag for either one overrides the file
+	if !flags.Changed("strict") && !flags.Changed("passthrough") {
+		setBool("strict", &c.strict, conf.Strict)
+		setBool("passthrough", &c.passthrough, conf.Passthrough)
+	}
+	return nil
+}
+
+// fileConverter gets the converter to use for a single file. If a report is wanted, then
+// the converter records diagnostics for the file, which are added to the report by calling done.
+// For mdx output, the front matter of the file has its position in the sidebar.
+func (c *convertCmd) fileConverter(conv *converter.DocumentConverter, file string) (fileConv *converter.DocumentConverter, done func(), err error) {
+	var opts []converter.Option
+	if position, exists := c.positions[file]; exists {
+		defaults := markdown.NewFrontMatter(markdown.FrontMatterNone)
+		defaults.Set("sidebar_position", position)
+		opts = append(opts, converter.WithFrontMatterDefaults(defaults))
+	}
+
+	done = func() {}
+	if c.report != "" {
+		diagnostics := converter.NewDiagnostics()
+		opts = append(opts, converter.WithDiagnostics(diagnostics))
+		done = func() {
+			c.reportMu.Lock()
+			defer c.reportMu.Unlock()
+			for _, diagnostic := range diagnostics.All() {
+				c.diagnostics = append(c.diagnostics, fileDiagnostic{File: file, Diagnostic: diagnostic})
+			}
+		}
+	}
+
+	if len(opts) == 0 {
+		return conv, done, nil
+	}
+	fileConv, err = c.newDocumentConverter(opts...)
+	return
+}
+
+// sidebarPositions numbers each file by its position among the files in the same directory,
+// starting at 1, so that Docusaurus orders the pages of the sidebar like the input directory
+func sidebarPositions(htmlFiles []string) map[string]int {
+	sorted := append([]string{}, htmlFiles...)
+	sort.Strings(sorted)
+
+	positions := map[string]int{}
+	counts := map[string]int{}
+	for _, htmlFile := range sorted {
+		dir := filepath.Dir(htmlFile)
+		counts[dir]++
+		positions[htmlFile] = counts[dir]
+	}
+	return positions
+}
+
+// printReport prints the diagnostics of all the files, if a report is wanted
+func (c *convertCmd) printReport(w io.Writer) error {
+	if c.report == "" {
+		return nil
+	}
+
+	// Files are converted concurrently, so sort the diagnostics by file to keep the report stable
+	sort.SliceStable(c.diagnostics, func(i, j int) bool { return c.diagnostics[i].File < c.diagnostics[j].File })
+
+	if c.report == "json" {
+		report, err := json.MarshalIndent(append([]fileDiagnostic{}, c.diagnostics...), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(report))
+		return err
+	}
+
+	for _, d := range c.diagnostics {
+		location := d.File
+		if d.Line > 0 {
+			location = fmt.Sprintf("%s:%d", d.File, d.Line)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s %s: %s\n", location, d.Kind, d.Path, d.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertStdin converts the html read from stdin. The markdown is written to stdout,
+// unless an output directory is given by --out or the configuration, in which case it is named after --stdin-filename.
+func (c *convertCmd) convertStdin(cmd *cobra.Command, conv *converter.DocumentConverter) error {
+	outV("Reading %s from stdin", c.stdinFilename)
+	conv, done, err := c.fileConverter(conv, c.stdinFilename)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	mdContent, err := conv.ConvertReaderContext(cmd.Context(), cmd.InOrStdin())
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.stdinFilename, err)
+	}
+
+	if c.toStdout || !(cmd.Flags().Changed("out") || c.outputDirConf) {
+		_, err = io.WriteString(cmd.OutOrStdout(), mdContent)
+		return err
+	}
+
+	outFile := filepath.Join(c.outputDir, strings.TrimSuffix(filepath.Base(c.stdinFilename), filepath.Ext(c.stdinFilename))+c.outputExt())
+	out("Converting %s to %s", c.stdinFilename, outFile)
+	if err := os.MkdirAll(c.outputDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(outFile, []byte(mdContent), 0755)
+}
+
+// convertResult is the result of converting a single file
+type convertResult struct {
+	htmlFile string
+	err      error
+}
+
+// convertFiles converts the files with a pool of workers, and gets the files that were converted,
+// in the order they finished. Every file is converted even if others fail, unless --fail-fast is set,
+// in which case no more files are started after the first failure. The error contains every file that failed.
+func (c *convertCmd) convertFiles(ctx context.Context, conv *converter.DocumentConverter, htmlFiles []string) (converted []string, err error) {
+	workers := c.jobs
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan string)
+	results := make(chan convertResult)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(jobs)
+		for _, htmlFile := range htmlFiles {
+			select {
+			case jobs <- htmlFile:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go c.convertFile(conv, htmlFile, &wg, errChan)
+		go func() {
+			defer wg.Done()
+			for htmlFile := range jobs {
+				results <- convertResult{htmlFile: htmlFile, err: c.convertFile(ctx, conv, htmlFile)}
+			}
+		}()
 	}
 
 	go func() {
 		wg.Wait()
-		close(wgDoneChan)
-		close(errChan)
+		close(results)
 	}()
 
-	select {
-	case <-wgDoneChan:
-		break
-	case convErr := <-errChan:
-		err = multierror.Append(err, convErr)
+	stopped := false
+	for result := range results {
+		if result.err == nil {
+			converted = append(converted, result.htmlFile)
+			continue
+		}
+
+		err = multierror.Append(err, fmt.Errorf("%s: %w", result.htmlFile, result.err))
+		if c.failFast && !stopped {
+			close(stop)
+			stopped = true
+		}
 	}
 
 	return
@@ -110,49 +510,173 @@ func (c *convertCmd) getInputFiles(htmlPath string) (htmlFiles []string, err err
 		return
 	}
 
-	if info.IsDir() {
-		searchDir := filepath.Join(htmlPath, "*.html")
-		outV("Input directory search path: %s", searchDir)
-		htmlFiles, err = filepath.Glob(searchDir)
+	if !info.IsDir() {
+		if !isInputFile(htmlPath) {
+			err = fmt.Errorf("only html files can be used as input. Got %s", filepath.Ext(htmlPath))
+			return
+		}
+		c.inputRoot = filepath.Dir(htmlPath)
+		htmlFiles = []string{htmlPath}
 		return
 	}
 
-	if ext := filepath.Ext(htmlPath); ext != ".html" {
-		err = fmt.Errorf("only html files can be used as input. Got %s", ext)
-		return
-	}
+	c.inputRoot = htmlPath
+	outV("Searching input directory %s", htmlPath)
+	err = filepath.WalkDir(htmlPath, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if path == htmlPath {
+			return nil
+		}
 
-	htmlFiles = []string{htmlPath}
+		rel, err := filepath.Rel(htmlPath, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if entry.IsDir() {
+			if !c.recursive || matchesAny(c.exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !isInputFile(path) || matchesAny(c.exclude, rel) {
+			return nil
+		}
+		if len(c.include) > 0 && !matchesAny(c.include, rel) {
+			return nil
+		}
+		htmlFiles = append(htmlFiles, path)
+		return nil
+	})
 	return
 }
 
+// getOutputFile places the markdown file at the same path relative to the output directory
+// as the html file is relative to the input directory.
+// For the mkdocs output format, the markdown is placed in the docs directory of the output directory.
 func (c *convertCmd) getOutputFile(htmlFile string) string {
-	return filepath.Join(c.outputDir, strings.TrimSuffix(filepath.Base(htmlFile), filepath.Ext(htmlFile))+".md")
+	rel, err := filepath.Rel(c.inputRoot, htmlFile)
+	if err != nil {
+		rel = filepath.Base(htmlFile)
+	}
+	rel = strings.TrimSuffix(rel, filepath.Ext(rel)) + c.outputExt()
+	if c.conf != nil {
+		rel = filepath.FromSlash(c.conf.MapPath(filepath.ToSlash(rel)))
+	}
+	if c.outputFormat == converter.OutputMkDocs {
+		rel = filepath.Join(mkdocsDocsDir, rel)
+	}
+	return filepath.Join(c.outputDir, rel)
+}
+
+// outputExt is the extension of the output files, which is ".json" for the json output format,
+// and ".mdx" for the mdx output format
+func (c *convertCmd) outputExt() string {
+	switch c.outputFormat {
+	case converter.OutputJSON:
+		return ".json"
+	case converter.OutputMDX:
+		return ".mdx"
+	}
+	return ".md"
 }
 
-func (c *convertCmd) convertFile(conv *converter.DocumentConverter, htmlPath string, wg *sync.WaitGroup, errs chan<- error) {
-	defer wg.Done()
+// checkOutputFiles checks that no two html files would be converted to the same markdown file,
+// such as "index.html" and "index.htm", before any files are written.
+// Paths are compared without case, since some file systems are not case sensitive.
+func (c *convertCmd) checkOutputFiles(htmlFiles []string) error {
+	var err error
+	outputs := map[string]string{}
+	for _, htmlFile := range htmlFiles {
+		outFile := c.getOutputFile(htmlFile)
+		key := strings.ToLower(outFile)
+		if other, exists := outputs[key]; exists {
+			err = multierror.Append(err, fmt.Errorf("%s and %s would both be converted to %s", other, htmlFile, outFile))
+			continue
+		}
+		outputs[key] = htmlFile
+	}
+	return err
+}
 
-	outFile := c.getOutputFile(htmlPath)
-	out("Converting %s to %s", htmlPath, outFile)
+// isInputFile checks whether the file has the extension of an html file
+func isInputFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, inputExt := range inputExtensions {
+		if ext == inputExt {
+			return true
+		}
+	}
+	return false
+}
 
-	f, err := os.Open(htmlPath)
+// matchesAny checks whether the slash separated path relative to the input directory
+// matches any of the glob patterns. A pattern without a "/" is matched against the
+// last element of the path, so "*.htm" matches files in any directory.
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *convertCmd) convertFile(ctx context.Context, conv *converter.DocumentConverter, htmlPath string) error {
+	conv, done, err := c.fileConverter(conv, htmlPath)
 	if err != nil {
-		errs <- err
-		return
+		return err
 	}
+	defer done()
 
-	htmlDoc, err := goquery.NewDocumentFromReader(f)
+	f, err := os.Open(htmlPath)
 	if err != nil {
-		errs <- err
-		return
+		return err
+	}
+	defer f.Close()
+
+	if c.toStdout {
+		outV("Converting %s", htmlPath)
+		mdContent, err := conv.ConvertReaderContext(ctx, f)
+		if err != nil {
+			return err
+		}
+		return c.writeStdout(mdContent)
 	}
 
-	mdContent := conv.DocumentToMarkdown(htmlDoc).String() + "\n"
+	outFile := c.getOutputFile(htmlPath)
+	out("Converting %s to %s", htmlPath, outFile)
+
+	if err := os.MkdirAll(filepath.Dir(outFile), 0755); err != nil {
+		return err
+	}
 
-	err = ioutil.WriteFile(outFile, []byte(mdContent), 0755)
+	mdContent, err := conv.ConvertReaderContext(ctx, f)
 	if err != nil {
-		errs <- err
-		return
+		return err
+	}
+
+	return ioutil.WriteFile(outFile, []byte(mdContent), 0755)
+}
+
+// writeStdout writes the markdown of a file to stdout.
+// The markdown of each file is separated by a blank line.
+func (c *convertCmd) writeStdout(mdContent string) error {
+	c.stdoutMu.Lock()
+	defer c.stdoutMu.Unlock()
+
+	if c.wroteStdout {
+		mdContent = "\n" + mdContent
 	}
+	c.wroteStdout = true
+	_, err := io.WriteString(c.stdout, mdContent)
+	return err
 }
@@ -0,0 +1,535 @@
+package htmltomd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/converter"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// runConvert runs a new convert command with the arguments and the content of stdin,
+// and gets what it wrote to stdout and stderr
+func runConvert(t *testing.T, stdin string, args ...string) (string, string, error) {
+	t.Helper()
+
+	cmd := newConvertCmd()
+	var stdout, stderr bytes.Buffer
+	cmd.SetArgs(args)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+
+	err := cmd.ExecuteContext(context.Background())
+	return stdout.String(), stderr.String(), err
+}
+
+// writeFiles writes each file in the directory, by its slash separated path relative to the directory
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFile reads the file, by its slash separated path relative to the directory
+func readFile(t *testing.T, dir string, name string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestMatchesAny(t *testing.T) {
+	tests := map[string]struct {
+		patterns []string
+		rel      string
+		expected bool
+	}{
+		"name in top directory":   {[]string{"*.htm"}, "page.htm", true},
+		"name in subdirectory":    {[]string{"*.htm"}, "guide/page.htm", true},
+		"directory name":          {[]string{"drafts"}, "guide/drafts", true},
+		"no match":                {[]string{"*.htm"}, "guide/page.html", false},
+		"path from input":         {[]string{"guide/*.html"}, "guide/page.html", true},
+		"path in other directory": {[]string{"guide/*.html"}, "api/guide/page.html", false},
+		"any pattern":             {[]string{"*.htm", "index.*"}, "index.html", true},
+		"no patterns":             {nil, "index.html", false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := matchesAny(test.patterns, test.rel); result != test.expected {
+				t.Errorf("Expected %t. Got %t", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestCheckOutputFiles(t *testing.T) {
+	input := filepath.Join(string(filepath.Separator), "input")
+	tests := map[string]struct {
+		htmlFiles []string
+		collision []string
+	}{
+		"different directories": {
+			htmlFiles: []string{"index.html", "guide/index.html"},
+		},
+		"different extensions": {
+			htmlFiles: []string{"index.html", "index.htm"},
+			collision: []string{"index.html", "index.htm", "index.md"},
+		},
+		"different case": {
+			htmlFiles: []string{"Guide/Page.html", "guide/page.xhtml"},
+			collision: []string{"Guide/Page.html", "guide/page.xhtml", "guide/page.md"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &convertCmd{inputRoot: input, outputDir: "out"}
+			var htmlFiles []string
+			for _, htmlFile := range test.htmlFiles {
+				htmlFiles = append(htmlFiles, filepath.Join(input, filepath.FromSlash(htmlFile)))
+			}
+
+			err := c.checkOutputFiles(htmlFiles)
+			if test.collision == nil {
+				if err != nil {
+					t.Errorf("Expected no error. Got %s", err)
+				}
+				return
+			}
+			expected := fmt.Sprintf("%s and %s would both be converted to %s",
+				filepath.Join(input, filepath.FromSlash(test.collision[0])),
+				filepath.Join(input, filepath.FromSlash(test.collision[1])),
+				filepath.Join("out", filepath.FromSlash(test.collision[2])))
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected %s. Got %v", expected, err)
+			}
+		})
+	}
+}
+
+func TestConvertRecursive(t *testing.T) {
+	input := t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"index.html":              "<p>Home</p>",
+		"guide/index.htm":         "<p>Guide</p>",
+		"guide/setup.xhtml":       "<p>Setup</p>",
+		"guide/drafts/next.html":  "<p>Draft</p>",
+		"api/reference.html":      "<p>Reference</p>",
+		"api/notes.txt":           "Not html",
+		"api/generated/page.html": "<p>Generated</p>",
+	})
+
+	tests := map[string]struct {
+		args     []string
+		expected []string
+	}{
+		"top directory": {
+			expected: []string{"index.md"},
+		},
+		"recursive": {
+			args:     []string{"--recursive"},
+			expected: []string{"api/generated/page.md", "api/reference.md", "guide/drafts/next.md", "guide/index.md", "guide/setup.md", "index.md"},
+		},
+		"exclude": {
+			args:     []string{"--recursive", "--exclude", "drafts", "--exclude", "api/generated"},
+			expected: []string{"api/reference.md", "guide/index.md", "guide/setup.md", "index.md"},
+		},
+		"include": {
+			args:     []string{"--recursive", "--include", "guide/*"},
+			expected: []string{"guide/index.md", "guide/setup.md"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output := t.TempDir()
+			if _, _, err := runConvert(t, "", append(test.args, "--out", output, input)...); err != nil {
+				t.Fatalf("Expected no error. Got %s", err)
+			}
+
+			var result []string
+			err := filepath.WalkDir(output, func(path string, entry fs.DirEntry, err error) error {
+				if err == nil && !entry.IsDir() {
+					rel, _ := filepath.Rel(output, path)
+					result = append(result, filepath.ToSlash(rel))
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(result, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("Expected %s. Got %s", test.expected, result)
+			}
+		})
+	}
+
+	// The content of each file is converted to the mirrored path
+	output := t.TempDir()
+	if _, _, err := runConvert(t, "", "--recursive", "--out", output, input); err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	if content := readFile(t, output, "guide/setup.md"); content != "Setup\n" {
+		t.Errorf("Expected %q. Got %q", "Setup\n", content)
+	}
+}
+
+func TestConvertFiles(t *testing.T) {
+	input := t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"a.html":     "<p>A</p>",
+		"b.html":     "<dl><dd>B</dd></dl>",
+		"c.html":     "<p>C</p>",
+		"d.html":     "<dl><dd>D</dd></dl>",
+		"e.html":     "<p>E</p>",
+		"guide.html": "<p>Guide</p>",
+	})
+
+	for _, jobs := range []int{1, 2, 4, 16} {
+		t.Run(fmt.Sprintf("%d jobs", jobs), func(t *testing.T) {
+			output := t.TempDir()
+			c := &convertCmd{jobs: jobs, strict: true, inputRoot: input, outputDir: output, outputFormat: "md", inputFormat: "html", spanFill: "blank"}
+			conv, err := c.newDocumentConverter()
+			if err != nil {
+				t.Fatal(err)
+			}
+			htmlFiles, err := c.getInputFiles(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			converted, err := c.convertFiles(context.Background(), conv, htmlFiles)
+
+			sort.Strings(converted)
+			var names []string
+			for _, htmlFile := range converted {
+				names = append(names, filepath.Base(htmlFile))
+			}
+			expected := "a.html,c.html,e.html,guide.html"
+			if strings.Join(names, ",") != expected {
+				t.Errorf("Expected %s. Got %s", expected, names)
+			}
+
+			merr, isMultiErr := err.(*multierror.Error)
+			if !isMultiErr || len(merr.Errors) != 2 {
+				t.Fatalf("Expected 2 errors. Got %v", err)
+			}
+			for _, name := range []string{"b.html", "d.html"} {
+				if !strings.Contains(err.Error(), filepath.Join(input, name)+": ") {
+					t.Errorf("Expected an error for %s. Got %s", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertSummary(t *testing.T) {
+	input := t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"a.html": "<p>A</p>",
+		"b.html": "<dl><dd>B</dd></dl>",
+		"c.html": "<p>C</p>",
+	})
+
+	tests := map[string]struct {
+		args     []string
+		expected string
+		fails    bool
+	}{
+		"all succeed": {
+			expected: "Converted 3 files: 3 succeeded, 0 failed, 0 skipped\n",
+		},
+		"some fail": {
+			args:     []string{"--strict", "--jobs", "2"},
+			expected: "Converted 3 files: 2 succeeded, 1 failed, 0 skipped\n",
+			fails:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout, _, err := runConvert(t, "", append(test.args, "--out", t.TempDir(), input)...)
+			if !strings.HasSuffix(stdout, test.expected) {
+				t.Errorf("Expected the summary %q. Got\n%s", test.expected, stdout)
+			}
+			if (err != nil) != test.fails {
+				t.Errorf("Expected an error only if a file failed. Got %v", err)
+			}
+		})
+	}
+}
+
+func TestConvertStdin(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"markdown to stdout": {
+			expected: "# Page\n\nContent\n",
+		},
+		"name without extension": {
+			args:     []string{"--stdin-filename", "page"},
+			expected: "# Page\n\nContent\n",
+		},
+		"output format": {
+			args:     []string{"--output-format", "mdx"},
+			expected: "---\ntitle: \"Page\"\nid: \"page\"\n---\n\n# Page\n\nContent\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout, _, err := runConvert(t, "<html><head><title>Page</title></head><body><p>Content</p></body></html>", append(test.args, "-")...)
+			if err != nil {
+				t.Fatalf("Expected no error. Got %s", err)
+			}
+			if stdout != test.expected {
+				t.Errorf("Expected\n%s\nGot\n%s", test.expected, stdout)
+			}
+		})
+	}
+}
+
+func TestConvertStdinToFile(t *testing.T) {
+	output := t.TempDir()
+
+	stdout, stderr, err := runConvert(t, "<p>Content</p>", "--stdin-filename", "page.html", "--out", output, "-")
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	if stdout != "" {
+		t.Errorf("Expected nothing on stdout. Got %s", stdout)
+	}
+	if !strings.Contains(stderr, "Converting page.html to ") {
+		t.Errorf("Expected a message on stderr. Got %s", stderr)
+	}
+	if content := readFile(t, output, "page.md"); content != "Content\n" {
+		t.Errorf("Expected %q. Got %q", "Content\n", content)
+	}
+}
+
+func TestConvertToStdout(t *testing.T) {
+	input := t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"b.html":       "<p>B</p>",
+		"a.html":       "<p>A</p>",
+		"guide/c.html": "<p>C</p>",
+	})
+
+	stdout, _, err := runConvert(t, "", "--stdout", "--recursive", "--jobs", "4", input)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	// Only the markdown is written to stdout, in the order of the files
+	expected := "A\n\nB\n\nC\n"
+	if stdout != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, stdout)
+	}
+}
+
+func TestConvertReport(t *testing.T) {
+	input := t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"b.html": "<html><body>\n<p>B</p>\n<dl><dd>Definition</dd></dl>\n</body></html>",
+		"a.html": "<html><body>\n<p>Some <u>underlined</u> text</p>\n</body></html>",
+	})
+
+	_, stderr, err := runConvert(t, "", "--report", "text", "--out", t.TempDir(), input)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	// The report is sorted by file
+	expected := filepath.Join(input, "a.html") + ":2: lossy html > body > p > u: markdown has no formatting like <u>, so only its text is kept\n" +
+		filepath.Join(input, "b.html") + ":3: skipped html > body > dl: <dl> is not converted\n"
+	if stderr != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, stderr)
+	}
+
+	_, stderr, err = runConvert(t, "", "--report", "json", "--out", t.TempDir(), input)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	var report []fileDiagnostic
+	if err := json.Unmarshal([]byte(stderr), &report); err != nil {
+		t.Fatalf("Expected a JSON report. Got %s", stderr)
+	}
+	if len(report) != 2 || report[1].File != filepath.Join(input, "b.html") || report[1].Kind != converter.DiagnosticSkipped || report[1].Line != 3 {
+		t.Errorf("Expected a skipped dl on line 3 of b.html. Got %+v", report)
+	}
+
+	_, _, err = runConvert(t, "", "--report", "xml", "--out", t.TempDir(), input)
+	expectedErr := `unknown report format "xml". Can be 'text' or 'json'`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected %s. Got %v", expectedErr, err)
+	}
+}
+
+func TestConvertAutoInputFormat(t *testing.T) {
+	input := t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"confluence.html": `<html><body>
+<div id="header">Navigation</div>
+<h1 id="title-text">Confluence Page</h1>
+<div id="main-content">
+	<div class="confluence-information-macro confluence-information-macro-tip">
+		<div class="confluence-information-macro-body"><p>Tip</p></div>
+	</div>
+</div>
+</body></html>`,
+		"page.html": `<html><head><title>Page</title></head><body><p>Content</p></body></html>`,
+	})
+	output := t.TempDir()
+
+	if _, _, err := runConvert(t, "", "--input-format", "auto", "--out", output, input); err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	// Only the main content of the Confluence page is converted
+	tests := map[string]string{
+		"confluence.md": "# Confluence Page\n\nTip\n",
+		"page.md":       "# Page\n\nContent\n",
+	}
+	for file, expected := range tests {
+		if content := readFile(t, output, file); content != expected {
+			t.Errorf("Expected\n%s\nGot\n%s", expected, content)
+		}
+	}
+}
+
+func TestConvertConfigPrecedence(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		out      string
+		expected map[string]string
+		fails    bool
+	}{
+		"configuration": {
+			out: "converted",
+			expected: map[string]string{
+				"index.md":      "---\ntitle: \"Home\"\n---\n\nHome\n",
+				"guide/page.md": "---\ntitle: \"Guide\"\n---\n\n<dl><dd>Definition</dd></dl>\n",
+			},
+		},
+		"flags override the configuration": {
+			args: []string{"--front-matter", "none", "--no-title-heading=false", "--out", "flags"},
+			out:  "flags",
+			expected: map[string]string{
+				"index.md":      "# Home\n\nHome\n",
+				"guide/page.md": "# Guide\n\n<dl><dd>Definition</dd></dl>\n",
+			},
+		},
+		"strict flag overrides passthrough in the configuration": {
+			args:     []string{"--strict", "--out", "strict"},
+			out:      "strict",
+			expected: map[string]string{"index.md": "---\ntitle: \"Home\"\n---\n\nHome\n"},
+			fails:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "input")
+			writeFiles(t, input, map[string]string{
+				"htmltomd.yaml":   "out: ../converted\nrecursive: true\nfront-matter: yaml\nno-title-heading: true\npassthrough: true\n",
+				"index.html":      "<html><head><title>Home</title></head><body><p>Home</p></body></html>",
+				"guide/page.html": "<html><head><title>Guide</title></head><body><dl><dd>Definition</dd></dl></body></html>",
+			})
+
+			// A relative --out is relative to the working directory, so make it absolute to be in the test directory
+			args := append([]string{}, test.args...)
+			for idx := range args {
+				if idx > 0 && args[idx-1] == "--out" {
+					args[idx] = filepath.Join(dir, args[idx])
+				}
+			}
+
+			_, _, err := runConvert(t, "", append(args, input)...)
+			if (err != nil) != test.fails {
+				t.Fatalf("Expected an error only if a file failed. Got %v", err)
+			}
+			for file, expected := range test.expected {
+				if content := readFile(t, filepath.Join(dir, test.out), file); content != expected {
+					t.Errorf("Expected %s to be\n%s\nGot\n%s", file, expected, content)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertStdinWithConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"htmltomd.yaml": "out: converted\nfront-matter: yaml\n"})
+	configFile := filepath.Join(dir, "htmltomd.yaml")
+
+	// The out of the configuration is used like a --out flag, so the markdown is written to a file named after the input
+	stdout, _, err := runConvert(t, "<html><head><title>Page</title></head><body><p>Content</p></body></html>", "--config", configFile, "--stdin-filename", "page.html", "-")
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	if stdout != "" {
+		t.Errorf("Expected nothing written to stdout. Got\n%s", stdout)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "converted", "page.md"))
+	if err != nil {
+		t.Fatalf("Expected the markdown file. Got %s", err)
+	}
+	expected := "---\ntitle: \"Page\"\n---\n\n# Page\n\nContent\n"
+	if string(content) != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, content)
+	}
+
+	// --stdout overrides the out of the configuration
+	stdout, _, err = runConvert(t, "<html><head><title>Page</title></head><body><p>Content</p></body></html>", "--config", configFile, "--stdout", "-")
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	if stdout != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, stdout)
+	}
+}
+
+func TestLoadConfigKeepsFlagsUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"htmltomd.yaml": "out: converted\njobs: 3\ninclude: [\"*.htm\"]\nstrict: true\n"})
+
+	cmd := newConvertCmd()
+	c := &convertCmd{configFile: filepath.Join(dir, "htmltomd.yaml"), jobs: 1}
+	if err := cmd.ParseFlags([]string{"--jobs", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.loadConfig(cmd, dir); err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	if c.outputDir != filepath.Join(dir, "converted") || c.jobs != 1 || strings.Join(c.include, ",") != "*.htm" || !c.strict {
+		t.Errorf("Expected the options of the configuration, except for --jobs. Got %+v", c)
+	}
+	for _, flag := range []string{"out", "include", "strict"} {
+		if cmd.Flags().Changed(flag) {
+			t.Errorf("Expected --%s to be unchanged", flag)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package htmltomd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/converter"
+	"github.com/spf13/cobra"
+)
+
+type detectCmd struct {
+}
+
+func init() {
+	c := detectCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "detect [input.html|input_directory|-]...",
+		Short: "detect the source of HTML file(s)",
+		Long: `Prints whether each file is a Confluence page, a Google Doc, a Word document, or other html,
+along with how confident the detection is. If a directory is given, then all ".html", ".htm",
+and ".xhtml" files in the directory are detected. If the input is "-", then the html is read from stdin.
+
+This is the source that "convert --input-format auto" uses for each file.`,
+		RunE: c.detect,
+		Args: cobra.MinimumNArgs(1),
+	}
+
+	rootCmd.AddCommand(cmd)
+}
+
+func (c *detectCmd) detect(cmd *cobra.Command, args []string) error {
+	var files []string
+	for _, arg := range args {
+		if arg == stdinArg {
+			files = append(files, arg)
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && isInputFile(entry.Name()) {
+				files = append(files, filepath.Join(arg, entry.Name()))
+			}
+		}
+	}
+
+	cmd.SilenceUsage = true
+	for _, file := range files {
+		detection, err := c.detectFile(cmd, file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", file, detection)
+		if len(detection.Signals) > 0 {
+			outV("  found %s", strings.Join(detection.Signals, ", "))
+		}
+	}
+	return nil
+}
+
+// detectFile detects the format of the file, or of stdin if the file is "-"
+func (c *detectCmd) detectFile(cmd *cobra.Command, file string) (converter.Detection, error) {
+	var r io.Reader = cmd.InOrStdin()
+	if file != stdinArg {
+		f, err := os.Open(file)
+		if err != nil {
+			return converter.Detection{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return converter.DetectReader(r)
+}
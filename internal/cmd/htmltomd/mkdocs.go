@@ -0,0 +1,209 @@
+package htmltomd
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// mkdocsConfigFile is the MkDocs configuration written to the output directory
+	mkdocsConfigFile = "mkdocs.yml"
+	// mkdocsDocsDir is the directory of the output directory that the markdown is placed in for MkDocs
+	mkdocsDocsDir = "docs"
+	// mkdocsIndexPage is the page of a directory, which is placed first in its section
+	mkdocsIndexPage = "index.md"
+)
+
+// mkdocsExtensions are the markdown extensions needed for the admonitions and code titles of the markdown
+var mkdocsExtensions = []string{"admonition", "pymdownx.details", "pymdownx.highlight", "pymdownx.superfences"}
+
+// writeMkDocsConfig writes the nav of the converted files to mkdocs.yml in the output directory,
+// so that the output directory can be built as a MkDocs site. If mkdocs.yml already exists,
+// then the pages that are not in its nav are added to it, and the rest of the file is kept.
+// Otherwise, it is created with the Material theme.
+func (c *convertCmd) writeMkDocsConfig(convertedFiles []string) error {
+	docsDir := filepath.Join(c.outputDir, mkdocsDocsDir)
+	var pages []string
+	for _, htmlFile := range convertedFiles {
+		rel, err := filepath.Rel(docsDir, c.getOutputFile(htmlFile))
+		if err != nil {
+			return err
+		}
+		pages = append(pages, filepath.ToSlash(rel))
+	}
+
+	configPath := filepath.Join(c.outputDir, mkdocsConfigFile)
+	var root *yaml.Node
+	content, err := os.ReadFile(configPath)
+	switch {
+	case err == nil:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return err
+		}
+		if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+			root = doc.Content[0]
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	if root == nil {
+		root = newMkDocsConfig(filepath.Base(c.inputRoot))
+	}
+	nav := mkdocsNav(pages, "")
+	if existing := getMapping(root, "nav"); existing != nil && existing.Kind == yaml.SequenceNode {
+		mergeMkDocsNav(existing, nav, navPages(existing, map[string]bool{}))
+	} else {
+		setMapping(root, "nav", nav)
+	}
+
+	var rendered bytes.Buffer
+	encoder := yaml.NewEncoder(&rendered)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	outV("Writing the nav of %d pages to %s", len(pages), configPath)
+	return os.WriteFile(configPath, rendered.Bytes(), 0644)
+}
+
+// newMkDocsConfig creates the configuration of a site with the Material theme
+func newMkDocsConfig(siteName string) *yaml.Node {
+	extensions := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, extension := range mkdocsExtensions {
+		extensions.Content = append(extensions.Content, yamlString(extension))
+	}
+	theme := &yaml.Node{Kind: yaml.MappingNode}
+	setMapping(theme, "name", yamlString("material"))
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	setMapping(root, "site_name", yamlString(siteName))
+	setMapping(root, "theme", theme)
+	setMapping(root, "markdown_extensions", extensions)
+	return root
+}
+
+// mkdocsNav creates the nav of the pages in the directory, which are slash separated paths relative
+// to the docs directory. The pages of the directory are listed first, starting with its index page,
+// followed by a section for each subdirectory, named after the subdirectory.
+func mkdocsNav(pages []string, dir string) *yaml.Node {
+	var dirPages, subdirs []string
+	subdirPages := map[string][]string{}
+	for _, page := range pages {
+		rel := strings.TrimPrefix(page, dir)
+		if idx := strings.Index(rel, "/"); idx >= 0 {
+			subdir := rel[:idx]
+			if _, exists := subdirPages[subdir]; !exists {
+				subdirs = append(subdirs, subdir)
+			}
+			subdirPages[subdir] = append(subdirPages[subdir], page)
+			continue
+		}
+		dirPages = append(dirPages, page)
+	}
+	sort.Slice(dirPages, func(i, j int) bool {
+		iIndex, jIndex := path.Base(dirPages[i]) == mkdocsIndexPage, path.Base(dirPages[j]) == mkdocsIndexPage
+		if iIndex != jIndex {
+			return iIndex
+		}
+		return dirPages[i] < dirPages[j]
+	})
+	sort.Strings(subdirs)
+
+	nav := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, page := range dirPages {
+		nav.Content = append(nav.Content, yamlString(page))
+	}
+	for _, subdir := range subdirs {
+		section := &yaml.Node{Kind: yaml.MappingNode}
+		setMapping(section, subdir, mkdocsNav(subdirPages[subdir], dir+subdir+"/"))
+		nav.Content = append(nav.Content, section)
+	}
+	return nav
+}
+
+// mergeMkDocsNav adds the pages of the generated nav that are not in the existing nav, so that the
+// titles and order of the existing nav are kept. The pages of a section are added to the section
+// of the existing nav with the same name, if it has one, and otherwise to a new section at the end.
+func mergeMkDocsNav(existing *yaml.Node, generated *yaml.Node, existingPages map[string]bool) {
+	for _, item := range generated.Content {
+		if item.Kind == yaml.ScalarNode {
+			if !existingPages[item.Value] {
+				existing.Content = append(existing.Content, item)
+			}
+			continue
+		}
+
+		name, pages := item.Content[0].Value, item.Content[1]
+		if section := navSection(existing, name); section != nil {
+			mergeMkDocsNav(section, pages, existingPages)
+			continue
+		}
+		added := &yaml.Node{Kind: yaml.SequenceNode}
+		mergeMkDocsNav(added, pages, existingPages)
+		if len(added.Content) > 0 {
+			section := &yaml.Node{Kind: yaml.MappingNode}
+			setMapping(section, name, added)
+			existing.Content = append(existing.Content, section)
+		}
+	}
+}
+
+// navPages adds the pages in the nav to the set, which may be listed on their own,
+// such as "index.md", or with a title, such as "Home: index.md"
+func navPages(nav *yaml.Node, pages map[string]bool) map[string]bool {
+	switch nav.Kind {
+	case yaml.ScalarNode:
+		pages[nav.Value] = true
+	case yaml.SequenceNode:
+		for _, item := range nav.Content {
+			navPages(item, pages)
+		}
+	case yaml.MappingNode:
+		for idx := 1; idx < len(nav.Content); idx += 2 {
+			navPages(nav.Content[idx], pages)
+		}
+	}
+	return pages
+}
+
+// navSection gets the pages of the section of the nav with the name, or nil if there is none
+func navSection(nav *yaml.Node, name string) *yaml.Node {
+	for _, item := range nav.Content {
+		if item.Kind == yaml.MappingNode && len(item.Content) == 2 && item.Content[0].Value == name && item.Content[1].Kind == yaml.SequenceNode {
+			return item.Content[1]
+		}
+	}
+	return nil
+}
+
+// getMapping gets the value of the key in the mapping node, or nil if the key does not exist
+func getMapping(mapping *yaml.Node, key string) *yaml.Node {
+	for idx := 0; idx+1 < len(mapping.Content); idx += 2 {
+		if mapping.Content[idx].Value == key {
+			return mapping.Content[idx+1]
+		}
+	}
+	return nil
+}
+
+// setMapping sets the value of the key in the mapping node, replacing the value if the key exists
+func setMapping(mapping *yaml.Node, key string, value *yaml.Node) {
+	for idx := 0; idx+1 < len(mapping.Content); idx += 2 {
+		if mapping.Content[idx].Value == key {
+			mapping.Content[idx+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, yamlString(key), value)
+}
+
+func yamlString(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,148 @@
+package htmltomd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// renderYAML renders the node as YAML, indented like mkdocs.yml
+func renderYAML(t *testing.T, node *yaml.Node) string {
+	t.Helper()
+
+	var rendered bytes.Buffer
+	encoder := yaml.NewEncoder(&rendered)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		t.Fatal(err)
+	}
+	return rendered.String()
+}
+
+func TestMkDocsNav(t *testing.T) {
+	tests := map[string]struct {
+		pages    []string
+		expected string
+	}{
+		"pages": {
+			pages:    []string{"b.md", "index.md", "a.md"},
+			expected: "- index.md\n- a.md\n- b.md\n",
+		},
+		"sections": {
+			pages:    []string{"guide/setup.md", "index.md", "api/index.md", "guide/index.md", "guide/advanced/tuning.md"},
+			expected: "- index.md\n- api:\n    - api/index.md\n- guide:\n    - guide/index.md\n    - guide/setup.md\n    - advanced:\n        - guide/advanced/tuning.md\n",
+		},
+		"no pages": {
+			expected: "[]\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := renderYAML(t, mkdocsNav(test.pages, ""))
+			if result != test.expected {
+				t.Errorf("Expected\n%s\nGot\n%s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestMergeMkDocsNav(t *testing.T) {
+	tests := map[string]struct {
+		existing string
+		pages    []string
+		expected string
+	}{
+		"titles and order are kept": {
+			existing: "- Home: index.md\n- b.md\n",
+			pages:    []string{"a.md", "b.md", "index.md"},
+			expected: "- Home: index.md\n- b.md\n- a.md\n",
+		},
+		"pages are added to the existing section": {
+			existing: "- index.md\n- guide:\n    - Setup: guide/setup.md\n",
+			pages:    []string{"index.md", "guide/setup.md", "guide/usage.md"},
+			expected: "- index.md\n- guide:\n    - Setup: guide/setup.md\n    - guide/usage.md\n",
+		},
+		"new sections are added at the end": {
+			existing: "- index.md\n- Old: old.md\n",
+			pages:    []string{"index.md", "api/index.md"},
+			expected: "- index.md\n- Old: old.md\n- api:\n    - api/index.md\n",
+		},
+		"pages in other sections are not added again": {
+			existing: "- index.md\n- Guides:\n    - guide/setup.md\n",
+			pages:    []string{"index.md", "guide/setup.md"},
+			expected: "- index.md\n- Guides:\n    - guide/setup.md\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(test.existing), &doc); err != nil {
+				t.Fatal(err)
+			}
+			existing := doc.Content[0]
+
+			mergeMkDocsNav(existing, mkdocsNav(test.pages, ""), navPages(existing, map[string]bool{}))
+
+			result := renderYAML(t, existing)
+			if result != test.expected {
+				t.Errorf("Expected\n%s\nGot\n%s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestConvertMkDocsNav(t *testing.T) {
+	input, output := filepath.Join(t.TempDir(), "site"), t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"index.html":       "<html><body><p>Home</p></body></html>",
+		"guide/setup.html": "<html><body><p>Setup</p></body></html>",
+		"bad.html":         "<html><body><dl><dd>Not converted</dd></dl></body></html>",
+	})
+	// Output of an earlier conversion is not converted again, so it is not in the nav
+	writeFiles(t, output, map[string]string{"docs/stale.md": "Stale"})
+
+	_, _, err := runConvert(t, "", "--output-format", "mkdocs", "--strict", "--recursive", "--out", output, input)
+	if err == nil {
+		t.Fatal("Expected an error for bad.html")
+	}
+
+	config := readFile(t, output, mkdocsConfigFile)
+	expected := `site_name: site
+theme:
+  name: material
+markdown_extensions:
+  - admonition
+  - pymdownx.details
+  - pymdownx.highlight
+  - pymdownx.superfences
+nav:
+  - index.md
+  - guide:
+      - guide/setup.md
+`
+	if config != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, config)
+	}
+
+	// An existing configuration is kept, and only the new pages are added to its nav
+	writeFiles(t, output, map[string]string{mkdocsConfigFile: "site_name: Docs\nnav:\n  - Home: index.md\n"})
+	if _, _, err := runConvert(t, "", "--output-format", "mkdocs", "--recursive", "--out", output, input); err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	config = readFile(t, output, mkdocsConfigFile)
+	expected = `site_name: Docs
+nav:
+  - Home: index.md
+  - bad.md
+  - guide:
+      - guide/setup.md
+`
+	if config != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, config)
+	}
+}
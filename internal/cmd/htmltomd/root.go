@@ -1,8 +1,12 @@
 package htmltomd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"sync"
 
 	"github.com/spf13/cobra"
 )
@@ -12,9 +16,16 @@ var (
 		Use:   "htmltomd",
 		Short: "converts HTML to Markdown",
 		Long:  `Reads a local HTML file and converts it into a Markdown document`,
+		// Errors are printed by Execute
+		SilenceErrors: true,
 	}
 	quiet   bool = false
 	verbose bool = false
+
+	// messages is where messages about the progress of a command are written
+	messages io.Writer = os.Stdout
+	// messagesMu serializes the messages, since files are converted concurrently
+	messagesMu sync.Mutex
 )
 
 func init() {
@@ -23,20 +34,31 @@ func init() {
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// An interrupt stops any conversions in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		stop()
 		os.Exit(1)
 	}
 }
 
 func out(format string, a ...interface{}) {
 	if !quiet {
-		fmt.Println(fmt.Sprintf(format, a...))
+		writeMessage(format, a...)
 	}
 }
 
 func outV(format string, a ...interface{}) {
 	if verbose {
-		fmt.Println(fmt.Sprintf(format, a...))
+		writeMessage(format, a...)
 	}
 }
+
+func writeMessage(format string, a ...interface{}) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	fmt.Fprintln(messages, fmt.Sprintf(format, a...))
+}
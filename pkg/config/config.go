@@ -0,0 +1,289 @@
+// Package config reads the configuration of the convert command from a YAML file,
+// so that a conversion can be repeated with the same options.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/converter"
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/hashicorp/go-multierror"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration file that is found in the input directory
+const FileName = "htmltomd.yaml"
+
+// Config is the configuration of the convert command. Each option with the same name as a
+// flag of the convert command is used unless the flag is given. The other options configure
+// how elements are found and converted, and where the markdown files are placed.
+type Config struct {
+	InputFormat    string   `yaml:"input-format"`
+	OutputFormat   string   `yaml:"output-format"`
+	Out            string   `yaml:"out"`
+	Recursive      *bool    `yaml:"recursive"`
+	Include        []string `yaml:"include"`
+	Exclude        []string `yaml:"exclude"`
+	Jobs           int      `yaml:"jobs"`
+	AsciiOnly      *bool    `yaml:"ascii-only"`
+	FrontMatter    string   `yaml:"front-matter"`
+	NoTitleHeading *bool    `yaml:"no-title-heading"`
+	SpanFill       string   `yaml:"span-fill"`
+	Strict         *bool    `yaml:"strict"`
+	Passthrough    *bool    `yaml:"passthrough"`
+	ExtractMain    *bool    `yaml:"extract-main"`
+	SkipChrome     []string `yaml:"skip-chrome"`
+
+	Text                TextConfig              `yaml:"text"`
+	Selectors           SelectorConfig          `yaml:"selectors"`
+	Handlers            []HandlerRule           `yaml:"handlers"`
+	Rewrite             []converter.RewriteRule `yaml:"rewrite"`
+	FrontMatterDefaults yaml.Node               `yaml:"front-matter-defaults"`
+	Paths               []PathMapping           `yaml:"paths"`
+}
+
+// TextConfig configures how text is cleaned. Each key in Replace is replaced with its value.
+type TextConfig struct {
+	Replace map[string]string `yaml:"replace"`
+}
+
+// SelectorConfig holds CSS selectors that override how the elements of a document are found.
+// Root is the element to convert, Title is the element with the title, and Content matches
+// the child elements to convert.
+type SelectorConfig struct {
+	Root    string `yaml:"root"`
+	Title   string `yaml:"title"`
+	Content string `yaml:"content"`
+}
+
+// HandlerRule converts the elements that match the selector with the action,
+// which can be "drop", "text", or "passthrough".
+type HandlerRule struct {
+	Selector string `yaml:"selector"`
+	Action   string `yaml:"action"`
+	Priority int    `yaml:"priority"`
+}
+
+// PathMapping places the markdown of the html files in the From directory, relative to the
+// input directory, in the To directory, relative to the output directory.
+type PathMapping struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// Find finds the configuration file in the directory, and whether it exists
+func Find(dir string) (string, bool) {
+	file := filepath.Join(dir, FileName)
+	info, err := os.Stat(file)
+	return file, err == nil && !info.IsDir()
+}
+
+// Load reads and validates the configuration file
+func Load(file string) (*Config, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return conf, nil
+}
+
+// Parse parses and validates the YAML configuration. Unknown options are an error.
+func Parse(content []byte) (*Config, error) {
+	conf := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(conf); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Validate checks every option, and gets an error that lists each invalid option
+func (c *Config) Validate() error {
+	var err error
+	invalid := func(format string, a ...interface{}) {
+		err = multierror.Append(err, fmt.Errorf(format, a...))
+	}
+
+	switch c.InputFormat {
+	case "", converter.InputHTML, converter.InputConfluence, converter.InputGoogle, converter.InputAuto:
+	default:
+		invalid("input-format: unknown input format %q", c.InputFormat)
+	}
+	if c.OutputFormat != "" {
+		if _, rendererErr := converter.GetRenderer(c.OutputFormat); rendererErr != nil {
+			invalid("output-format: %s", rendererErr)
+		}
+	}
+	if c.FrontMatter != "" {
+		if _, parseErr := markdown.ParseFrontMatterFormat(c.FrontMatter); parseErr != nil {
+			invalid("front-matter: %s", parseErr)
+		}
+	}
+	if c.SpanFill != "" {
+		if _, parseErr := converter.ParseSpanFill(c.SpanFill); parseErr != nil {
+			invalid("span-fill: %s", parseErr)
+		}
+	}
+	if c.Strict != nil && c.Passthrough != nil && *c.Strict && *c.Passthrough {
+		invalid("strict and passthrough cannot both be set")
+	}
+	if c.Jobs < 0 {
+		invalid("jobs: must not be negative")
+	}
+	for _, name := range c.SkipChrome {
+		if _, parseErr := converter.ParseChrome(name); parseErr != nil {
+			invalid("skip-chrome: %s", parseErr)
+		}
+	}
+	for _, pattern := range append(append([]string{}, c.Include...), c.Exclude...) {
+		if _, matchErr := path.Match(pattern, ""); matchErr != nil {
+			invalid("invalid glob pattern %q: %s", pattern, matchErr)
+		}
+	}
+
+	for _, selector := range []struct{ key, value string }{
+		{"root", c.Selectors.Root}, {"title", c.Selectors.Title}, {"content", c.Selectors.Content},
+	} {
+		if selector.value == "" {
+			continue
+		}
+		if _, parseErr := cascadia.ParseGroup(selector.value); parseErr != nil {
+			invalid("selectors.%s: invalid selector %q: %s", selector.key, selector.value, parseErr)
+		}
+	}
+	for i, rule := range c.Handlers {
+		if _, parseErr := cascadia.ParseGroup(rule.Selector); parseErr != nil {
+			invalid("handlers[%d]: invalid selector %q: %s", i, rule.Selector, parseErr)
+		}
+		if action, parseErr := converter.ParseElementAction(rule.Action); parseErr != nil {
+			invalid("handlers[%d]: %s", i, parseErr)
+		} else if action == converter.ElementFail {
+			invalid("handlers[%d]: a handler cannot fail. Use 'drop', 'text', or 'passthrough'", i)
+		}
+	}
+
+	if _, rewriteErr := converter.NewRewriter(c.Rewrite); rewriteErr != nil {
+		invalid("rewrite: %s", rewriteErr)
+	}
+
+	if _, defaultsErr := c.frontMatterDefaults(); defaultsErr != nil {
+		invalid("front-matter-defaults: %s", defaultsErr)
+	}
+	for i, mapping := range c.Paths {
+		if cleanPath(mapping.From) == "" {
+			invalid("paths[%d]: from must be a directory in the input directory", i)
+		}
+	}
+
+	return err
+}
+
+// Options gets the converter options for the options that do not have a flag
+func (c *Config) Options() ([]converter.Option, error) {
+	var opts []converter.Option
+	if len(c.Text.Replace) > 0 {
+		opts = append(opts, converter.WithTextReplacements(c.Text.Replace))
+	}
+	if c.Selectors.Root != "" {
+		opts = append(opts, converter.WithRootSelector(c.Selectors.Root))
+	}
+	if c.Selectors.Title != "" {
+		opts = append(opts, converter.WithTitleSelector(c.Selectors.Title))
+	}
+	if c.Selectors.Content != "" {
+		opts = append(opts, converter.WithContentSelector(c.Selectors.Content))
+	}
+
+	for _, rule := range c.Handlers {
+		action, err := converter.ParseElementAction(rule.Action)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, converter.WithElementRules(converter.ElementRule{Selector: rule.Selector, Action: action, Priority: rule.Priority}))
+	}
+
+	if len(c.Rewrite) > 0 {
+		opts = append(opts, converter.WithRewriteRules(c.Rewrite...))
+	}
+
+	defaults, err := c.frontMatterDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if defaults != nil {
+		opts = append(opts, converter.WithFrontMatterDefaults(defaults))
+	}
+
+	return opts, nil
+}
+
+// MapPath maps the slash separated path of a markdown file relative to the output directory
+// with the first PathMapping whose From directory contains it. The path is returned as is
+// if no mapping contains it.
+func (c *Config) MapPath(rel string) string {
+	for _, mapping := range c.Paths {
+		from := cleanPath(mapping.From)
+		if rel == from || strings.HasPrefix(rel, from+"/") {
+			return path.Join(cleanPath(mapping.To), strings.TrimPrefix(rel, from))
+		}
+	}
+	return rel
+}
+
+// cleanPath cleans the slash separated relative path, so "./docs/" is "docs" and "." is ""
+func cleanPath(p string) string {
+	return strings.Trim(path.Clean("/"+p), "/")
+}
+
+// frontMatterDefaults gets the front matter defaults in the order they are in the file.
+// Values can be scalars or lists of scalars.
+func (c *Config) frontMatterDefaults() (*markdown.FrontMatter, error) {
+	node := &c.FrontMatterDefaults
+	if node.Kind == 0 {
+		return nil, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("must be a map of keys to values")
+	}
+
+	defaults := markdown.NewFrontMatter(markdown.FrontMatterNone)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		switch value.Kind {
+		case yaml.ScalarNode:
+			var scalar interface{}
+			if err := value.Decode(&scalar); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			defaults.Set(key, scalar)
+		case yaml.SequenceNode:
+			var list []string
+			if err := value.Decode(&list); err != nil {
+				return nil, fmt.Errorf("%s: must be a list of values", key)
+			}
+			defaults.Set(key, list)
+		default:
+			return nil, fmt.Errorf("%s: must be a value or a list of values", key)
+		}
+	}
+	return defaults, nil
+}
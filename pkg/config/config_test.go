@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/converter"
+)
+
+const testConfig = `
+input-format: confluence
+recursive: true
+front-matter: yaml
+selectors:
+  title: h1.title
+handlers:
+  - selector: div.ad
+    action: drop
+rewrite:
+  - selector: div.warning-box
+    action: component
+    component: blockquote
+front-matter-defaults:
+  draft: true
+  tags: [imported]
+paths:
+  - from: ./old/
+    to: archive
+`
+
+func TestParse(t *testing.T) {
+	conf, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	if conf.InputFormat != converter.InputConfluence {
+		t.Errorf("Expected %s. Got %s", converter.InputConfluence, conf.InputFormat)
+	}
+	if conf.Recursive == nil || !*conf.Recursive {
+		t.Errorf("Expected recursive to be set")
+	}
+	if conf.AsciiOnly != nil {
+		t.Errorf("Expected ascii-only to not be set")
+	}
+	if len(conf.Rewrite) != 1 || conf.Rewrite[0].Component != converter.ComponentBlockquote {
+		t.Errorf("Expected a blockquote rewrite rule. Got %v", conf.Rewrite)
+	}
+
+	defaults, err := conf.frontMatterDefaults()
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	expected := "draft,tags"
+	if keys := strings.Join(defaults.Keys(), ","); keys != expected {
+		t.Errorf("Expected %s. Got %s", expected, keys)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse([]byte(`
+output-format: html
+skip-chrome: [sidebar]
+selectors:
+  root: "[["
+handlers:
+  - selector: div
+    action: fail
+rewrite:
+  - selector: span.kbd
+    action: replace
+`))
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	for _, expected := range []string{"output-format", "skip-chrome", "selectors.root", "handlers[0]", "rewrite"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %s in %s", expected, err)
+		}
+	}
+}
+
+func TestParseUnknownOption(t *testing.T) {
+	if _, err := Parse([]byte("input_format: html\n")); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestMapPath(t *testing.T) {
+	conf, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	tests := map[string]string{
+		"old/page.md":       "archive/page.md",
+		"old/dir/page.md":   "archive/dir/page.md",
+		"older/page.md":     "older/page.md",
+		"guides/old/doc.md": "guides/old/doc.md",
+	}
+	for rel, expected := range tests {
+		if result := conf.MapPath(rel); result != expected {
+			t.Errorf("Expected %s. Got %s", expected, result)
+		}
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(file, []byte(testConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if found, exists := Find(filepath.Dir(file)); !exists || found != file {
+		t.Fatalf("Expected %s to be found", file)
+	}
+
+	conf, err := Load(file)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	opts, err := conf.Options()
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	result, err := converter.ConvertString(`
+<html>
+	<body>
+		<h1 class="title">Page Title</h1>
+		<p>Paragraph</p>
+		<div class="ad"><p>Advertisement</p></div>
+	</body>
+</html>`, append(opts, converter.WithFrontMatter("yaml"), converter.WithTitleHeading(false))...)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "---\ntitle: \"Page Title\"\ndraft: true\ntags:\n  - \"imported\"\n---\n\n## Page Title\n\nParagraph\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
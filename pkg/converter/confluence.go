@@ -1,95 +1,74 @@
 package converter
 
 import (
-	"fmt"
+	"context"
+	"regexp"
 	"strings"
 
 	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
-	"github.com/david-mk-lawrence/htmltomd/pkg/util"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
 var (
-	confluencePanelNoteClass    = "panel" // Confluence handle's "note" panels differently for some reason
-	confluencePanelContentClass = "confluence-information-macro-body"
-	confluencePanelInfoClass    = "confluence-information-macro-information"
-	confluencePanelWarningClass = "confluence-information-macro-note"
-	confluencePanelTipClass     = "confluence-information-macro-tip"
-	confluencePanelErrorClass   = "confluence-information-macro-warning"
+	confluencePanelNoteClass     = "panel" // Confluence handle's "note" panels differently for some reason
+	confluencePanelContentClass  = "confluence-information-macro-body"
+	confluencePanelInfoClass     = "confluence-information-macro-information"
+	confluencePanelWarningClass  = "confluence-information-macro-note"
+	confluencePanelTipClass      = "confluence-information-macro-tip"
+	confluencePanelErrorClass    = "confluence-information-macro-warning"
+	confluenceExpandContentClass = "expand-content"
+	confluenceLabelSelector      = ".labels-content .label, #labels-section .aui-label"
+	confluenceDatePattern        = regexp.MustCompile(`on\s+([A-Z][a-z]{2} \d{1,2}, \d{4})`)
+)
+
+const (
+	// confluencePanelSelector matches panels. Confluence "note" panels only have the "panel" class.
+	confluencePanelSelector = `div[class="panel"], div.confluence-information-macro`
+	// confluencePanelTitleSelector matches the title of a panel
+	confluencePanelTitleSelector = ".title, .panelHeader"
+	// confluenceCodeBlockSelector matches code blocks
+	confluenceCodeBlockSelector = "div.code"
+	// confluenceCodeTitleSelector matches the title of a code block
+	confluenceCodeTitleSelector = ".codeHeader"
+	// confluenceExpandSelector matches expand macros, which hide their content until they are expanded
+	confluenceExpandSelector = "div.expand-container"
 )
 
 // ConfluenceSelectionConverter converts the Confluence HTML page to markdown.
-// Tags controls which HTML tags will be searched when looking for content.
-// If not set, then the defaultTags will be used.
+// In addition to the standard HTML elements, it converts Confluence panels, expand macros, and code blocks.
 type ConfluenceSelectionConverter struct {
-	Transformer            *Transformer
-	RootElementFinder      FindDocumentSelection
-	TitleFinder            FindText
-	ContentSelector        FindSelection
-	ContentSelectorHandler HandleSelection
+	SelectionConverterBase
 }
 
 // NewConfluenceSelectionConverter intializes a ConfluenceSelectionConverter with default function calls.
 func NewConfluenceSelectionConverter(conf SelectionConverterConfig) *ConfluenceSelectionConverter {
 	c := &ConfluenceSelectionConverter{}
-
-	if conf.Transformer != nil {
-		c.Transformer = conf.Transformer
-		if c.Transformer.textCleaner == nil {
-			c.Transformer.textCleaner = NewTextCleaner(nil)
-		}
-	} else {
-		c.Transformer = NewTransformer(nil)
-	}
-
-	if conf.RootElementFinder != nil {
-		c.RootElementFinder = conf.RootElementFinder
-	} else {
-		c.RootElementFinder = c.defaultRootElementFinder
-	}
-
-	if conf.TitleFinder != nil {
-		c.TitleFinder = conf.TitleFinder
-	} else {
-		c.TitleFinder = c.defaultTitleFinder
-	}
-
-	if conf.ContentSelector != nil {
-		c.ContentSelector = conf.ContentSelector
-	} else {
-		c.ContentSelector = c.defaultContentSelector
-	}
-
-	if conf.ContentSelectorHandler != nil {
-		c.ContentSelectorHandler = conf.ContentSelectorHandler
-	} else {
-		c.ContentSelectorHandler = c.defaultContentSelectorHandler
-	}
+	c.init(conf, SelectionConverterConfig{
+		RootElementFinder: c.defaultRootElementFinder,
+		TitleFinder:       c.defaultTitleFinder,
+		MetadataFinder:    c.defaultMetadataFinder,
+		ContentSelector:   c.defaultContentSelector,
+	})
+
+	c.Handlers.mustHandle(confluenceCodeBlockSelector, PriorityConverter, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		mdDoc.AddContent(c.toCodeBlock(elm))
+	})
+	c.Handlers.mustHandleContext(confluencePanelSelector, PriorityConverter, func(ctx context.Context, i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMDContext) error {
+		panel, err := c.toPanel(ctx, elm, mdDoc.GetRenderConfig(), toMD)
+		mdDoc.AddContent(panel)
+		return err
+	})
+	c.Handlers.mustHandleContext(confluenceExpandSelector, PriorityConverter, func(ctx context.Context, i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMDContext) error {
+		expand, err := c.toExpand(ctx, elm, mdDoc.GetRenderConfig(), toMD)
+		mdDoc.AddContent(expand)
+		return err
+	})
+	c.Handlers.Merge(conf.Handlers)
 
 	return c
 }
 
-// FindRootElement finds the root element.
-func (c *ConfluenceSelectionConverter) FindRootElement(doc *goquery.Document) *goquery.Selection {
-	return c.RootElementFinder(doc)
-}
-
-// FindTitle finds the title of the document.
-func (c *ConfluenceSelectionConverter) FindTitle(doc *goquery.Document) string {
-	return c.TitleFinder(doc)
-}
-
-// FindContentElements finds the selections that that should be iterated over for content
-func (c *ConfluenceSelectionConverter) FindContentElements(s *goquery.Selection) *goquery.Selection {
-	return c.ContentSelector(s)
-}
-
-// HandleMatchedSelection handles matched selections from FindContentElements.
-func (c *ConfluenceSelectionConverter) HandleMatchedSelection(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
-	c.ContentSelectorHandler(i, elm, mdDoc, toMD)
-}
-
 func (c *ConfluenceSelectionConverter) defaultRootElementFinder(doc *goquery.Document) *goquery.Selection {
 	return doc.Find("#main-content").First()
 }
@@ -98,69 +77,71 @@ func (c *ConfluenceSelectionConverter) defaultTitleFinder(doc *goquery.Document)
 	return c.Transformer.CleanText(doc.Find("#title-text").First().Text())
 }
 
-func (c *ConfluenceSelectionConverter) defaultContentSelector(s *goquery.Selection) *goquery.Selection {
-	return s.ChildrenFiltered(DefaultSearchPattern)
-}
+// defaultMetadataFinder finds the author and dates from the page metadata, which
+// looks like "Created by <author>, last modified by <editor> on Mar 03, 2020",
+// and the labels of the page.
+func (c *ConfluenceSelectionConverter) defaultMetadataFinder(doc *goquery.Document) *markdown.FrontMatter {
+	frontMatter := findHTMLMetadata(doc, c.Transformer)
 
-func (c *ConfluenceSelectionConverter) defaultContentSelectorHandler(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
-	c.Transformer.RemoveScripts(elm)
-	c.Transformer.ReplaceAll(elm)
-
-	tag := elm.Nodes[0].Data
-	switch tag {
-	case "p", "span":
-		mdDoc.AddParagraph(c.Transformer.CleanText(elm.Text()))
-	case "hr":
-		mdDoc.AddHorizontalRule()
-	case "h1", "h2", "h3", "h4", "h5", "h6":
-		mdDoc.AddHeader(tag, c.Transformer.CleanText(elm.Text()))
-	case "ul", "ol":
-		mdDoc.AddContent(c.Transformer.ToList(elm))
-	case "table":
-		mdDoc.AddContent(c.Transformer.ToTable(elm))
-	case "div":
-		if c.isPanel(elm) {
-			mdDoc.AddDoc(c.toPanel(elm, mdDoc.GetRenderConfig(), toMD))
-		} else if c.isCodeBlock(elm) {
-			mdDoc.AddContent(c.toCodeBlock(elm))
+	pageMetadata := doc.Find(".page-metadata").First()
+	if author := c.Transformer.CleanText(pageMetadata.Find(".author").First().Text()); author != "" {
+		frontMatter.Set("author", author)
+	}
+	if match := confluenceDatePattern.FindStringSubmatch(c.Transformer.CleanText(pageMetadata.Text())); match != nil {
+		if strings.Contains(pageMetadata.Text(), "last modified") {
+			frontMatter.Set("lastmod", parseDate(match[1]))
 		} else {
-			// Recurse through the div
-			mdDoc.AddDoc(toMD(elm, mdDoc.GetRenderConfig()))
+			frontMatter.Set("date", parseDate(match[1]))
 		}
 	}
+
+	var labels []string
+	doc.Find(confluenceLabelSelector).Each(func(i int, s *goquery.Selection) {
+		if label := c.Transformer.CleanText(s.Text()); label != "" {
+			labels = append(labels, label)
+		}
+	})
+	if len(labels) > 0 {
+		frontMatter.Set("tags", labels)
+	}
+
+	return frontMatter
 }
 
-func (c *ConfluenceSelectionConverter) toPanel(elm *goquery.Selection, docConf markdown.DocConfig, toMD SelectionToMD) *markdown.Doc {
+func (c *ConfluenceSelectionConverter) defaultContentSelector(s *goquery.Selection) *goquery.Selection {
+	return s.ChildrenFiltered(DefaultSearchPattern)
+}
+
+// toPanel converts the panel to an admonition, which the Renderer may render as just its content.
+// The admonition has the content converted up to any error converting it.
+func (c *ConfluenceSelectionConverter) toPanel(ctx context.Context, elm *goquery.Selection, docConf markdown.DocConfig, toMD SelectionToMDContext) (markdown.Admonition, error) {
 	// Recursively convert the content in the panel since it may contain lists, code blocks, etc
 	// which will have been missed by the root since they aren't direct children
-	doc := toMD(elm.Find("."+confluencePanelContentClass).First(), docConf)
-
-	// Only markdown for hugo will be converted to panels
-	if c.Transformer.format != "hugo" {
-		return doc
-	}
+	doc, err := toMD(ctx, elm.Find("."+confluencePanelContentClass).First(), docConf)
 
-	noticeType := "note"
+	kind := AdmonitionNote
 	if elm.HasClass(confluencePanelNoteClass) {
-		noticeType = "note"
+		kind = AdmonitionNote
 	} else if elm.HasClass(confluencePanelInfoClass) {
-		noticeType = "info"
+		kind = AdmonitionInfo
 	} else if elm.HasClass(confluencePanelWarningClass) {
-		noticeType = "warning"
+		kind = AdmonitionWarning
 	} else if elm.HasClass(confluencePanelTipClass) {
-		noticeType = "tip"
+		kind = AdmonitionTip
 	} else if elm.HasClass(confluencePanelErrorClass) {
-		noticeType = "error"
+		kind = AdmonitionError
 	}
 
-	// Wrap the content with another document with a single space as separator
-	// This will place the shortcode wrappers directory before and after the content
-	wrapper := markdown.NewDoc(markdown.DocConfig{Separator: util.String("\n")})
-	wrapper.AddParagraph(fmt.Sprintf("{{%% notice %s %%}}", noticeType))
-	wrapper.AddDoc(doc)
-	wrapper.AddParagraph("{{% /notice %}}")
+	title := c.Transformer.CleanText(elm.ChildrenFiltered(confluencePanelTitleSelector).First().Text())
+	return markdown.Admonition{Kind: kind, Title: title, Content: doc}, err
+}
 
-	return wrapper
+// toExpand converts the expand macro to a collapsible admonition, with the text of its control as the title.
+// The admonition has the content converted up to any error converting it.
+func (c *ConfluenceSelectionConverter) toExpand(ctx context.Context, elm *goquery.Selection, docConf markdown.DocConfig, toMD SelectionToMDContext) (markdown.Admonition, error) {
+	doc, err := toMD(ctx, elm.Find("."+confluenceExpandContentClass).First(), docConf)
+	title := c.Transformer.CleanText(elm.Find(".expand-control-text").First().Text())
+	return markdown.Admonition{Kind: AdmonitionNote, Title: title, Collapsible: true, Content: doc}, err
 }
 
 func (c *ConfluenceSelectionConverter) toCodeBlock(elm *goquery.Selection) markdown.CodeBlock {
@@ -183,19 +164,6 @@ func (c *ConfluenceSelectionConverter) toCodeBlock(elm *goquery.Selection) markd
 		lang = "txt"
 	}
 
-	return markdown.CodeBlock{Lang: lang, Code: preBlock.Text()}
-}
-
-func (c *ConfluenceSelectionConverter) isCodeBlock(elm *goquery.Selection) bool {
-	return elm.HasClass("code")
-}
-
-func (c *ConfluenceSelectionConverter) isPanel(elm *goquery.Selection) bool {
-	// This is checking that the *only* class is panel, which is different than HasClass
-	// Confluence treats "note" panels differently than other types, and has only the "panel" class
-	if class, _ := elm.Attr("class"); class == confluencePanelNoteClass {
-		return true
-	}
-
-	return elm.HasClass("confluence-information-macro")
+	title := c.Transformer.CleanText(elm.ChildrenFiltered(confluenceCodeTitleSelector).First().Text())
+	return markdown.CodeBlock{Lang: lang, Title: title, Code: preBlock.Text()}
 }
@@ -1,6 +1,10 @@
 package converter
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+)
 
 func TestDefaultConfluenceConverter(t *testing.T) {
 	doc := newTestDoc(`
@@ -42,6 +46,9 @@ func TestDefaultConfluenceConverter(t *testing.T) {
 					<td>Data 2</td>
 				</tr>
 			</table>
+			<blockquote>
+				<p>Quote</p>
+			</blockquote>
 			<div class="code">
 				<div>
 					<pre>Pre without highlight params</pre>
@@ -116,6 +123,8 @@ Test Paragraph 3
 | --- | --- |
 | Data 1 | Data 2 |
 
+> Quote
+
 ` + "```txt\nPre without highlight params\n```\n\n" + "```txt\nPre with default highlight params\n```\n\n" + "```python\nimport math\nprint(math.pi)\n```\n\n" + `Note Panel
 
 Info Panel
@@ -130,3 +139,42 @@ Error Panel`
 		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
 	}
 }
+
+func TestConfluenceConverterFrontMatter(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<body>
+		<span id="title-text">Test Doc</span>
+		<div class="page-metadata">
+			Created by <span class='author'> Jane Doe</span>, last modified by <span class='editor'> John Doe</span> on Mar 03, 2020
+		</div>
+		<div id="main-content">
+			<p>Paragraph</p>
+		</div>
+		<div class="labels-content">
+			<a class="label">runbook</a>
+			<a class="label">ops</a>
+		</div>
+	</body>
+</html>
+`)
+
+	s := NewConfluenceSelectionConverter(SelectionConverterConfig{})
+	c := NewDocumentConverter(s, &DocumentConverterConf{FrontMatterFormat: markdown.FrontMatterTOML})
+
+	result := c.DocumentToMarkdown(doc).String()
+	expected := `+++
+title = "Test Doc"
+author = "Jane Doe"
+lastmod = 2020-03-03T00:00:00Z
+tags = ["runbook", "ops"]
++++
+
+# Test Doc
+
+Paragraph`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
@@ -0,0 +1,150 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ContainerSearchPattern matches the structural elements of HTML5 pages, which are
+// recursed through like a "div"
+const ContainerSearchPattern = "main,article,section,header,footer,nav,aside,figure,figcaption,details,summary"
+
+// Chrome is a kind of element around the content of a page, such as its navigation
+type Chrome string
+
+const (
+	// ChromeNav is navigation, such as "nav" elements
+	ChromeNav Chrome = "nav"
+	// ChromeHeader is the header of the page, such as a "header" element that is not in an article or section
+	ChromeHeader Chrome = "header"
+	// ChromeFooter is the footer of the page, such as a "footer" element that is not in an article or section
+	ChromeFooter Chrome = "footer"
+)
+
+// chromeRoles are the ARIA roles of each kind of chrome
+var chromeRoles = map[string]Chrome{
+	"navigation":  ChromeNav,
+	"banner":      ChromeHeader,
+	"contentinfo": ChromeFooter,
+}
+
+// sectioningTags are the elements whose header and footer belong to them, rather than to the page
+var sectioningTags = map[string]bool{
+	"article": true, "aside": true, "main": true, "nav": true, "section": true,
+}
+
+// phrasingTags are inline elements that are neither formatted nor reported as lossy
+var phrasingTags = map[string]bool{
+	"span": true, "time": true, "label": true, "wbr": true, "bdi": true, "bdo": true, "data": true,
+}
+
+// ParseChrome gets the Chrome by name. Can be "nav", "header", or "footer".
+func ParseChrome(name string) (Chrome, error) {
+	switch chrome := Chrome(strings.ToLower(name)); chrome {
+	case ChromeNav, ChromeHeader, ChromeFooter:
+		return chrome, nil
+	default:
+		return "", fmt.Errorf("unknown page chrome %q. Can be '%s', '%s', or '%s'", name, ChromeNav, ChromeHeader, ChromeFooter)
+	}
+}
+
+// RemoveChrome removes the elements of the kinds of chrome from the DOM element.
+func (t *Transformer) RemoveChrome(elm *goquery.Selection, chrome []Chrome) {
+	if len(chrome) == 0 {
+		return
+	}
+
+	skip := map[Chrome]bool{}
+	for _, c := range chrome {
+		skip[c] = true
+	}
+	elm.Find("nav,header,footer,[role]").FilterFunction(func(i int, s *goquery.Selection) bool {
+		return skip[chromeKind(s.Nodes[0])]
+	}).Remove()
+}
+
+// chromeKind gets the kind of chrome the node is, or "" if it is not chrome
+func chromeKind(node *html.Node) Chrome {
+	if role, ok := nodeAttr(node, "role"); ok {
+		if chrome, exists := chromeRoles[strings.TrimSpace(role)]; exists {
+			return chrome
+		}
+	}
+
+	switch node.Data {
+	case "nav":
+		return ChromeNav
+	case "header", "footer":
+		for parent := node.Parent; parent != nil; parent = parent.Parent {
+			if parent.Type == html.ElementNode && sectioningTags[parent.Data] {
+				return ""
+			}
+		}
+		return Chrome(node.Data)
+	}
+	return ""
+}
+
+// WrapLooseText wraps each run of text and inline elements that are directly in the DOM element
+// in a "p" element, so that it is converted as a paragraph. Two or more "br" elements in a row
+// end the paragraph.
+func (t *Transformer) WrapLooseText(elm *goquery.Selection) {
+	for _, node := range elm.Nodes {
+		var run []*html.Node
+		breaks := 0
+		flush := func() {
+			wrapRun(node, run)
+			run = nil
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch {
+			case child.Type == html.ElementNode && child.Data == "br":
+				breaks++
+				if breaks == 2 {
+					flush()
+				} else if breaks < 2 {
+					run = append(run, child)
+				}
+			case child.Type == html.TextNode && strings.TrimSpace(child.Data) == "", child.Type == html.CommentNode:
+				if len(run) > 0 {
+					run = append(run, child)
+				}
+			case child.Type == html.TextNode, child.Type == html.ElementNode && isLooseInline(child.Data):
+				breaks = 0
+				run = append(run, child)
+			default:
+				breaks = 0
+				flush()
+			}
+		}
+		flush()
+	}
+}
+
+// isLooseInline checks whether the tag is an inline element that can be part of a paragraph
+func isLooseInline(tag string) bool {
+	return inlineTags[tag] || unsupportedInlineTags[tag] || phrasingTags[tag]
+}
+
+// wrapRun moves the nodes in the run, which are children of the parent, into a new "p" element.
+// Nodes without content at the end of the run, such as line breaks, are left out of it.
+func wrapRun(parent *html.Node, run []*html.Node) {
+	for len(run) > 0 && !hasContent(run[len(run)-1]) {
+		run = run[:len(run)-1]
+	}
+	if len(run) == 0 {
+		return
+	}
+
+	p := &html.Node{Type: html.ElementNode, Data: "p", DataAtom: atom.P}
+	parent.InsertBefore(p, run[0])
+	for _, node := range run {
+		parent.RemoveChild(node)
+		p.AppendChild(node)
+	}
+}
@@ -0,0 +1,383 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+const (
+	// InputHTML converts arbitrary HTML
+	InputHTML = "html"
+	// InputConfluence converts Confluence pages exported as HTML
+	InputConfluence = "confluence"
+	// InputGoogle converts Google Docs exported as HTML
+	InputGoogle = "google"
+
+	// OutputMarkdown renders standard markdown
+	OutputMarkdown = "md"
+	// OutputHugo renders markdown with shortcodes for a Hugo website
+	OutputHugo = "hugo"
+	// OutputJSON renders the tree of the markdown document as JSON
+	OutputJSON = "json"
+	// OutputMDX renders MDX for a Docusaurus website
+	OutputMDX = "mdx"
+	// OutputMkDocs renders markdown for a MkDocs website with the Material theme
+	OutputMkDocs = "mkdocs"
+)
+
+// Option configures how ConvertReader, ConvertString, ConvertFile, and NewConverter convert HTML.
+type Option func(*options)
+
+type options struct {
+	inputFormat         string
+	outputFormat        string
+	renderer            Renderer
+	asciiOnly           bool
+	frontMatter         markdown.FrontMatterFormat
+	frontMatterSet      bool
+	titleHeading        bool
+	reduceHeaders       bool
+	spanFill            SpanFill
+	diagnostics         *Diagnostics
+	policy              ElementPolicy
+	skipChrome          []Chrome
+	extractMain         bool
+	replacements        map[string]string
+	rootSelector        string
+	titleSelector       string
+	contentSelector     string
+	rules               []ElementRule
+	frontMatterDefaults *markdown.FrontMatter
+	rewriteRules        []RewriteRule
+}
+
+// WithInputFormat sets the kind of HTML being converted.
+// Can be "html", "confluence", "google", or "auto" to detect the format of each document.
+// Defaults to "html".
+func WithInputFormat(format string) Option {
+	return func(o *options) { o.inputFormat = format }
+}
+
+// WithOutputFormat sets the flavor of markdown that is rendered, by the name of a registered Renderer.
+// Can be "md", "hugo", "json", "mdx", "mkdocs", or the name of a Renderer added with RegisterRenderer. Defaults to "md".
+func WithOutputFormat(format string) Option {
+	return func(o *options) { o.outputFormat = format }
+}
+
+// WithRenderer renders the markdown with the Renderer, instead of the Renderer of the output format
+func WithRenderer(renderer Renderer) Option {
+	return func(o *options) { o.renderer = renderer }
+}
+
+// WithAsciiOnly removes all non-ascii characters from the markdown
+func WithAsciiOnly(asciiOnly bool) Option {
+	return func(o *options) { o.asciiOnly = asciiOnly }
+}
+
+// WithFrontMatter renders the title and metadata of the document as front matter in the format.
+// If the Renderer is a FrontMatterRenderer, then the format defaults to its format, and can
+// only be set to that format or to FrontMatterNone.
+func WithFrontMatter(format markdown.FrontMatterFormat) Option {
+	return func(o *options) {
+		o.frontMatter = format
+		o.frontMatterSet = true
+	}
+}
+
+// WithTitleHeading sets whether the title is rendered as a header. Defaults to true.
+func WithTitleHeading(titleHeading bool) Option {
+	return func(o *options) { o.titleHeading = titleHeading }
+}
+
+// WithReduceHeaders sets whether headers are reduced one level to make room for the title.
+// Defaults to true.
+func WithReduceHeaders(reduceHeaders bool) Option {
+	return func(o *options) { o.reduceHeaders = reduceHeaders }
+}
+
+// WithSpanFill sets how table cells covered by a "colspan" or "rowspan" are filled
+func WithSpanFill(spanFill SpanFill) Option {
+	return func(o *options) { o.spanFill = spanFill }
+}
+
+// WithDiagnostics records content that is left out of the markdown, or loses some of
+// its formatting, in the Diagnostics. Use a new Diagnostics for each document.
+func WithDiagnostics(diagnostics *Diagnostics) Option {
+	return func(o *options) { o.diagnostics = diagnostics }
+}
+
+// WithElementPolicy sets what is done with elements that are not converted, such as
+// "dl" or "iframe". Defaults to DropPolicy, which leaves them out of the markdown.
+func WithElementPolicy(policy ElementPolicy) Option {
+	return func(o *options) { o.policy = policy }
+}
+
+// WithSkipChrome leaves the kinds of chrome around the content of the page,
+// such as its navigation, out of the markdown
+func WithSkipChrome(chrome ...Chrome) Option {
+	return func(o *options) { o.skipChrome = chrome }
+}
+
+// WithExtractMain converts only the main content of the page, as found by FindMainContent,
+// instead of the whole body
+func WithExtractMain(extractMain bool) Option {
+	return func(o *options) { o.extractMain = extractMain }
+}
+
+// WithTextReplacements replaces each key in the text with its value, such as "→" with "->"
+func WithTextReplacements(replacements map[string]string) Option {
+	return func(o *options) { o.replacements = replacements }
+}
+
+// WithRootSelector converts the first element that matches the CSS selector, instead of the root
+// element found for the input format
+func WithRootSelector(selector string) Option {
+	return func(o *options) { o.rootSelector = selector }
+}
+
+// WithTitleSelector uses the text of the first element that matches the CSS selector as the title,
+// instead of the title found for the input format
+func WithTitleSelector(selector string) Option {
+	return func(o *options) { o.titleSelector = selector }
+}
+
+// WithContentSelector converts the child elements that match the CSS selector, instead of the
+// elements matched for the input format
+func WithContentSelector(selector string) Option {
+	return func(o *options) { o.contentSelector = selector }
+}
+
+// WithElementRules converts the elements matched by each rule with its action
+func WithElementRules(rules ...ElementRule) Option {
+	return func(o *options) { o.rules = append(o.rules, rules...) }
+}
+
+// WithFrontMatterDefaults adds the keys of the defaults to the front matter of each document
+// that does not already have them. The defaults of each use of the option are merged.
+func WithFrontMatterDefaults(defaults *markdown.FrontMatter) Option {
+	return func(o *options) {
+		if o.frontMatterDefaults == nil {
+			o.frontMatterDefaults = markdown.NewFrontMatter(markdown.FrontMatterNone)
+		}
+		o.frontMatterDefaults.Merge(defaults)
+	}
+}
+
+// WithRewriteRules changes the elements matched by each rule before the document is converted
+func WithRewriteRules(rules ...RewriteRule) Option {
+	return func(o *options) { o.rewriteRules = append(o.rewriteRules, rules...) }
+}
+
+// NewConverter creates a DocumentConverter configured by the options.
+// The converter can be reused to convert many documents.
+func NewConverter(opts ...Option) (*DocumentConverter, error) {
+	o := options{
+		inputFormat:   InputHTML,
+		outputFormat:  OutputMarkdown,
+		titleHeading:  true,
+		reduceHeaders: true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	renderer := o.renderer
+	if renderer == nil {
+		var err error
+		if renderer, err = GetRenderer(o.outputFormat); err != nil {
+			return nil, err
+		}
+	}
+	if fmRenderer, hasFrontMatter := renderer.(FrontMatterRenderer); hasFrontMatter {
+		format := fmRenderer.FrontMatterFormat()
+		if !o.frontMatterSet {
+			o.frontMatter = format
+		} else if o.frontMatter != markdown.FrontMatterNone && o.frontMatter != format {
+			return nil, fmt.Errorf("the %s output format only supports %s front matter. Got %s", renderer.Name(), format, o.frontMatter)
+		}
+	}
+
+	var rewriter *Rewriter
+	if len(o.rewriteRules) > 0 {
+		var err error
+		if rewriter, err = NewRewriter(o.rewriteRules); err != nil {
+			return nil, err
+		}
+	}
+
+	textCleaner := NewTextCleaner(&TextCleanerConf{AsciiOnly: o.asciiOnly, Replacements: o.replacements})
+	transformer := NewTransformer(&TransformerConf{
+		Renderer:      renderer,
+		TextCleaner:   textCleaner,
+		SpanFill:      &o.spanFill,
+		Diagnostics:   o.diagnostics,
+		ElementPolicy: o.policy,
+	})
+	conf := SelectionConverterConfig{
+		Transformer:   transformer,
+		ElementPolicy: o.policy,
+		SkipChrome:    o.skipChrome,
+	}
+	if o.extractMain {
+		conf.RootElementFinder = FindMainContent
+	}
+	if err := o.applySelectors(&conf); err != nil {
+		return nil, err
+	}
+	if len(o.rules) > 0 {
+		conf.Handlers = NewHandlerRegistry()
+		if err := transformer.registerElementRules(conf.Handlers, o.rules); err != nil {
+			return nil, err
+		}
+	}
+
+	var selConv SelectionConverter
+	var selConvFinder FindSelectionConverter
+	switch o.inputFormat {
+	case InputHTML:
+		selConv = NewHTMLSelectionConverter(conf)
+	case InputConfluence:
+		selConv = NewConfluenceSelectionConverter(conf)
+	case InputGoogle:
+		selConv = NewGoogleSelectionConverter(conf)
+	case InputAuto:
+		selConv = NewHTMLSelectionConverter(conf)
+		selConvFinder = newAutoSelectionConverter(conf)
+	default:
+		return nil, fmt.Errorf("unknown input format %q. Can be '%s', '%s', '%s', or '%s'", o.inputFormat, InputHTML, InputConfluence, InputGoogle, InputAuto)
+	}
+
+	return NewDocumentConverter(selConv, &DocumentConverterConf{
+		Renderer:            renderer,
+		TextCleaner:         textCleaner,
+		FrontMatterFormat:   o.frontMatter,
+		TitleHeading:        &o.titleHeading,
+		ReduceHeaders:       &o.reduceHeaders,
+		Diagnostics:         o.diagnostics,
+		SelectionConvFinder: selConvFinder,
+		FrontMatterDefaults: o.frontMatterDefaults,
+		Rewriter:            rewriter,
+		ElementPolicy:       o.policy,
+	}), nil
+}
+
+// applySelectors sets the hooks of the configuration that find elements with the CSS selectors
+func (o *options) applySelectors(conf *SelectionConverterConfig) error {
+	for _, selector := range []string{o.rootSelector, o.titleSelector, o.contentSelector} {
+		if selector == "" {
+			continue
+		}
+		if _, err := cascadia.ParseGroup(selector); err != nil {
+			return fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+	}
+
+	if rootSelector := o.rootSelector; rootSelector != "" {
+		conf.RootElementFinder = func(doc *goquery.Document) *goquery.Selection {
+			return doc.Find(rootSelector).First()
+		}
+	}
+	if titleSelector := o.titleSelector; titleSelector != "" {
+		transformer := conf.Transformer
+		conf.TitleFinder = func(doc *goquery.Document) string {
+			return transformer.CleanText(doc.Find(titleSelector).First().Text())
+		}
+	}
+	if contentSelector := o.contentSelector; contentSelector != "" {
+		conf.ContentSelector = func(s *goquery.Selection) *goquery.Selection {
+			return s.ChildrenFiltered(contentSelector)
+		}
+	}
+	return nil
+}
+
+// ConvertReader converts the HTML read from the reader to markdown
+func ConvertReader(r io.Reader, opts ...Option) (string, error) {
+	c, err := NewConverter(opts...)
+	if err != nil {
+		return "", err
+	}
+	return c.ConvertReader(r)
+}
+
+// ConvertString converts the HTML to markdown
+func ConvertString(content string, opts ...Option) (string, error) {
+	return ConvertReader(strings.NewReader(content), opts...)
+}
+
+// ConvertFile converts the HTML file at the path to markdown
+func ConvertFile(path string, opts ...Option) (string, error) {
+	c, err := NewConverter(opts...)
+	if err != nil {
+		return "", err
+	}
+	return c.ConvertFile(path)
+}
+
+// ConvertReader converts the HTML read from the reader to markdown.
+// The markdown ends with a newline.
+func (c *DocumentConverter) ConvertReader(r io.Reader) (string, error) {
+	return c.ConvertReaderContext(context.Background(), r)
+}
+
+// ConvertReaderContext converts the HTML read from the reader to markdown,
+// and stops converting when the context is done.
+// If the converter has Diagnostics, or its Renderer is a DocumentRenderer, then the source is
+// indexed so that diagnostics and blocks have the lines they were converted from.
+// The markdown is rendered by the Renderer of the converter, and ends with a newline.
+func (c *DocumentConverter) ConvertReaderContext(ctx context.Context, r io.Reader) (string, error) {
+	_, rendersDocument := c.Renderer.(DocumentRenderer)
+
+	var source []byte
+	if c.Diagnostics != nil || rendersDocument {
+		var err error
+		if source, err = io.ReadAll(r); err != nil {
+			return "", err
+		}
+		r = bytes.NewReader(source)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return "", err
+	}
+	if source != nil {
+		lines := sourceLines(source, doc)
+		c.Diagnostics.indexLines(lines)
+		if rendersDocument {
+			ctx = withSourceLines(ctx, lines)
+		}
+	}
+	mdDoc, err := c.DocumentToMarkdownContext(ctx, doc)
+	if err != nil {
+		return "", err
+	}
+	return Render(c.Renderer, mdDoc)
+}
+
+// ConvertString converts the HTML to markdown.
+// The markdown ends with a newline.
+func (c *DocumentConverter) ConvertString(content string) (string, error) {
+	return c.ConvertReader(strings.NewReader(content))
+}
+
+// ConvertFile converts the HTML file at the path to markdown.
+// The markdown ends with a newline.
+func (c *DocumentConverter) ConvertFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return c.ConvertReader(f)
+}
@@ -0,0 +1,148 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+)
+
+const convertTestHTML = `
+<html>
+	<head>
+		<title>Page Title</title>
+		<meta name="author" content="Jane Doe">
+	</head>
+	<body>
+		<h1>Header</h1>
+		<p>Some <strong>bold</strong> text</p>
+	</body>
+</html>
+`
+
+func TestConvertString(t *testing.T) {
+	result, err := ConvertString(convertTestHTML)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "# Page Title\n\n## Header\n\nSome **bold** text\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestConvertStringWithOptions(t *testing.T) {
+	result, err := ConvertString(convertTestHTML,
+		WithFrontMatter(markdown.FrontMatterYAML),
+		WithTitleHeading(false),
+		WithReduceHeaders(false),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "---\ntitle: \"Page Title\"\nauthor: \"Jane Doe\"\n---\n\n# Header\n\nSome **bold** text\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestConvertStringWithFrontMatterDefaults(t *testing.T) {
+	defaults := markdown.NewFrontMatter(markdown.FrontMatterNone)
+	defaults.Set("author", "Nobody")
+	defaults.Set("draft", true)
+	moreDefaults := markdown.NewFrontMatter(markdown.FrontMatterNone)
+	moreDefaults.Set("weight", 2)
+
+	result, err := ConvertString(convertTestHTML,
+		WithFrontMatter(markdown.FrontMatterYAML),
+		WithFrontMatterDefaults(defaults),
+		WithFrontMatterDefaults(moreDefaults),
+		WithTitleHeading(false),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "---\ntitle: \"Page Title\"\nauthor: \"Jane Doe\"\ndraft: true\nweight: 2\n---\n\n## Header\n\nSome **bold** text\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestConvertFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(path, []byte(convertTestHTML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ConvertFile(path, WithAsciiOnly(true))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "# Page Title\n\n## Header\n\nSome **bold** text\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+
+	if _, err := ConvertFile(filepath.Join(t.TempDir(), "missing.html")); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestConvertUnknownFormats(t *testing.T) {
+	if _, err := ConvertString(convertTestHTML, WithInputFormat("word")); err == nil {
+		t.Errorf("Expected an error for an unknown input format")
+	}
+	if _, err := ConvertString(convertTestHTML, WithOutputFormat("rst")); err == nil {
+		t.Errorf("Expected an error for an unknown output format")
+	}
+}
+
+func TestConvertStringWithSelectorsAndRules(t *testing.T) {
+	content := `
+<html>
+	<head>
+		<title>Ignored Title</title>
+	</head>
+	<body>
+		<div id="nav"><p>Navigation</p></div>
+		<div id="content">
+			<h1 class="title">Page Title</h1>
+			<p>Step 1 → Step 2</p>
+			<div class="note"><p>A <em>note</em></p></div>
+			<div class="ad"><p>Advertisement</p></div>
+		</div>
+	</body>
+</html>`
+
+	result, err := ConvertString(content,
+		WithRootSelector("#content"),
+		WithTitleSelector("h1.title"),
+		WithTextReplacements(map[string]string{"→": "->"}),
+		WithElementRules(
+			ElementRule{Selector: "div.note", Action: ElementText},
+			ElementRule{Selector: "div.ad", Action: ElementDrop},
+		),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "# Page Title\n\n## Page Title\n\nStep 1 -> Step 2\n\nA _note_\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestConvertStringWithInvalidRule(t *testing.T) {
+	if _, err := ConvertString("<p>text</p>", WithContentSelector("[[")); err == nil {
+		t.Error("Expected an error for an invalid selector")
+	}
+	if _, err := ConvertString("<p>text</p>", WithElementRules(ElementRule{Selector: "p", Action: ElementFail})); err == nil {
+		t.Error("Expected an error for a rule that fails")
+	}
+}
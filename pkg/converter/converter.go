@@ -2,14 +2,18 @@
 package converter
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
 
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
 )
 
 // DefaultSearchPattern defines a default pattern to search for elements that will
 // contain content for the markdown document
-const DefaultSearchPattern = "p,span,hr,h1,h2,h3,h4,h5,h6,ul,ol,div,table"
+const DefaultSearchPattern = "p,span,hr,h1,h2,h3,h4,h5,h6,ul,ol,div,table,blockquote,pre"
 
 // FindDocumentSelection is a callable that finds DOM elements in the given the Document
 type FindDocumentSelection func(*goquery.Document) *goquery.Selection
@@ -17,6 +21,10 @@ type FindDocumentSelection func(*goquery.Document) *goquery.Selection
 // FindText is a callable that finds text in the given the HTMLDoc
 type FindText func(*goquery.Document) string
 
+// FindFrontMatter is a callable that finds metadata about the given Document,
+// such as the author, dates, and labels.
+type FindFrontMatter func(*goquery.Document) *markdown.FrontMatter
+
 // FindSelection is a callable that finds DOM elements in the given selection
 type FindSelection func(*goquery.Selection) *goquery.Selection
 
@@ -27,15 +35,53 @@ type SelectionToMD func(*goquery.Selection, markdown.DocConfig) *markdown.Doc
 // add to, and a callable to convert child elements to markdown documents
 type HandleSelection func(int, *goquery.Selection, *markdown.Doc, SelectionToMD)
 
+// SelectionToMDContext is a callable that converts a selection to a markdown document,
+// and can fail or be cancelled
+type SelectionToMDContext func(context.Context, *goquery.Selection, markdown.DocConfig) (*markdown.Doc, error)
+
+// HandleSelectionContext is a callable like HandleSelection that can fail, or be cancelled
+// with a context. It returns any error from converting child elements.
+type HandleSelectionContext func(context.Context, int, *goquery.Selection, *markdown.Doc, SelectionToMDContext) error
+
+// FindSelectionConverter is a callable that chooses the SelectionConverter for the given Document
+type FindSelectionConverter func(*goquery.Document) SelectionConverter
+
 // DocumentConverter is a struct that can convert an HTML document into a markdown document
 type DocumentConverter struct {
-	SelectionConv SelectionConverter
-	TextCleaner   *TextCleaner
+	Renderer            Renderer
+	SelectionConv       SelectionConverter
+	SelectionConvFinder FindSelectionConverter
+	TextCleaner         *TextCleaner
+	FrontMatterFormat   markdown.FrontMatterFormat
+	TitleHeading        *bool
+	ReduceHeaders       *bool
+	Diagnostics         *Diagnostics
+	FrontMatterDefaults *markdown.FrontMatter
+	Rewriter            *Rewriter
+	ElementPolicy       ElementPolicy
 }
 
 // DocumentConverterConf is the configuration for a DocumentConverter
 type DocumentConverterConf struct {
-	TextCleaner *TextCleaner
+	// Renderer renders the document, and defaults to a MarkdownRenderer
+	Renderer Renderer
+	// ElementPolicy fails the conversion at unconverted content if the policy is to fail for it
+	ElementPolicy ElementPolicy
+	// FrontMatterDefaults are added to the front matter unless the document has its own values
+	FrontMatterDefaults *markdown.FrontMatter
+	// Rewriter changes the document with its rules before it is converted
+	Rewriter *Rewriter
+	// SelectionConvFinder chooses the SelectionConverter for each document, such as by its detected format
+	SelectionConvFinder FindSelectionConverter
+	TextCleaner         *TextCleaner
+	// FrontMatterFormat renders the title and any metadata as front matter, unless it is FrontMatterNone
+	FrontMatterFormat markdown.FrontMatterFormat
+	// TitleHeading controls whether the title is also rendered as a header, which defaults to true
+	TitleHeading *bool
+	// ReduceHeaders controls whether headers are reduced one level to make room for the title, which defaults to true
+	ReduceHeaders *bool
+	// Diagnostics records the content that is left out of the markdown
+	Diagnostics *Diagnostics
 }
 
 // SelectionConverter is an interface that converts a style of HTML document to markdown.
@@ -47,13 +93,34 @@ type SelectionConverter interface {
 	HandleMatchedSelection(int, *goquery.Selection, *markdown.Doc, SelectionToMD)
 }
 
+// ContextSelectionConverter is an optional interface for a SelectionConverter whose
+// handling of selections can fail, or be cancelled with a context. A SelectionConverter that
+// does not implement it is adapted with NewContextSelectionConverter.
+type ContextSelectionConverter interface {
+	SelectionConverter
+	HandleMatchedSelectionContext(context.Context, int, *goquery.Selection, *markdown.Doc, SelectionToMDContext) error
+}
+
+// MetadataConverter is an optional interface for a SelectionConverter that can find
+// metadata about the document. The metadata is rendered as front matter.
+type MetadataConverter interface {
+	FindMetadata(*goquery.Document) *markdown.FrontMatter
+}
+
 // SelectionConverterConfig contains parameters that a SelectionConvert will can use to be more customizable
 type SelectionConverterConfig struct {
 	Transformer            *Transformer
 	RootElementFinder      FindDocumentSelection
 	TitleFinder            FindText
+	MetadataFinder         FindFrontMatter
 	ContentSelector        FindSelection
 	ContentSelectorHandler HandleSelection
+	// Handlers are registered in addition to the handlers of the SelectionConverter, to add or override conversions
+	Handlers *HandlerRegistry
+	// ElementPolicy decides what to do with elements that are not matched by the ContentSelector
+	ElementPolicy ElementPolicy
+	// SkipChrome lists the kinds of chrome around the content, such as navigation, to leave out
+	SkipChrome []Chrome
 }
 
 // NewDocumentConverter creates a new DocumentConverter with the given SelectionConverter and configuration
@@ -65,27 +132,244 @@ func NewDocumentConverter(selectionConv SelectionConverter, conf *DocumentConver
 		textCleaner = NewTextCleaner(nil)
 	}
 
-	return &DocumentConverter{SelectionConv: selectionConv, TextCleaner: textCleaner}
+	c := &DocumentConverter{SelectionConv: selectionConv, TextCleaner: textCleaner}
+	if conf != nil {
+		c.FrontMatterFormat = conf.FrontMatterFormat
+		c.TitleHeading = conf.TitleHeading
+		c.ReduceHeaders = conf.ReduceHeaders
+		c.Diagnostics = conf.Diagnostics
+		c.SelectionConvFinder = conf.SelectionConvFinder
+		c.FrontMatterDefaults = conf.FrontMatterDefaults
+		c.Rewriter = conf.Rewriter
+		c.Renderer = conf.Renderer
+		c.ElementPolicy = conf.ElementPolicy
+	}
+
+	return c
 }
 
-// DocumentToMarkdown converts the HTML doc to markdown
+// DocumentToMarkdown converts the HTML doc to markdown.
+// Any errors are ignored, and the elements that failed are left out of the markdown.
+// Use DocumentToMarkdownContext to handle errors.
 func (c *DocumentConverter) DocumentToMarkdown(doc *goquery.Document) *markdown.Doc {
+	mdDoc, _ := c.DocumentToMarkdownContext(context.Background(), doc)
+	return mdDoc
+}
+
+// DocumentToMarkdownContext converts the HTML doc to markdown.
+// Conversion stops at the first error, or when the context is done. The error is
+// a *ConversionError that identifies the element being converted at the time.
+// The markdown converted up to that point is returned with the error.
+func (c *DocumentConverter) DocumentToMarkdownContext(ctx context.Context, doc *goquery.Document) (*markdown.Doc, error) {
+	if c.SelectionConvFinder != nil {
+		// Convert with a copy, so the converter can be used for other documents at the same time
+		docConv := *c
+		docConv.SelectionConv = c.SelectionConvFinder(doc)
+		docConv.SelectionConvFinder = nil
+		return docConv.DocumentToMarkdownContext(ctx, doc)
+	}
+
+	c.Rewriter.Rewrite(doc.Selection)
 	root := c.SelectionConv.FindRootElement(doc)
 	title := c.TextCleaner.CleanText(c.SelectionConv.FindTitle(doc))
-	mdDoc := c.SelectionToMarkdown(root, markdown.DocConfig{Title: &title})
+	return c.SelectionToMarkdownContext(ctx, root, markdown.DocConfig{
+		Title:         &title,
+		FrontMatter:   c.findFrontMatter(doc, title),
+		TitleHeading:  c.TitleHeading,
+		ReduceHeaders: c.ReduceHeaders,
+	})
+}
 
-	return mdDoc
+// findFrontMatter creates the front matter with the title followed by any metadata
+// from the SelectionConverter. There is no front matter if no format is configured.
+func (c *DocumentConverter) findFrontMatter(doc *goquery.Document, title string) *markdown.FrontMatter {
+	if c.FrontMatterFormat == markdown.FrontMatterNone {
+		return nil
+	}
+
+	frontMatter := markdown.NewFrontMatter(c.FrontMatterFormat)
+	if title != "" {
+		frontMatter.Set("title", title)
+	}
+	if metadataConv, ok := c.SelectionConv.(MetadataConverter); ok {
+		frontMatter.Merge(metadataConv.FindMetadata(doc))
+	}
+	if c.FrontMatterDefaults != nil {
+		for _, key := range c.FrontMatterDefaults.Keys() {
+			if _, exists := frontMatter.Get(key); !exists {
+				value, _ := c.FrontMatterDefaults.Get(key)
+				frontMatter.Set(key, value)
+			}
+		}
+	}
+
+	return frontMatter
 }
 
 // SelectionToMarkdown creates a new markdown document, and searches for content to add to the markdown doc.
 // It hands off handling of matched selections to the SelectionConverter since it depends heavily
 // on the HTML structure of the original document.
+// Any errors are ignored. Use SelectionToMarkdownContext to handle errors.
 func (c *DocumentConverter) SelectionToMarkdown(elm *goquery.Selection, docConf markdown.DocConfig) *markdown.Doc {
-	mdDoc := markdown.NewDoc(docConf)
+	mdDoc, _ := c.SelectionToMarkdownContext(context.Background(), elm, docConf)
+	return mdDoc
+}
+
+// SelectionToMarkdownContext creates a new markdown document from the content in the selection,
+// like SelectionToMarkdown. Conversion stops at the first error, or when the context is done.
+func (c *DocumentConverter) SelectionToMarkdownContext(ctx context.Context, elm *goquery.Selection, docConf markdown.DocConfig) (mdDoc *markdown.Doc, err error) {
+	mdDoc = markdown.NewDoc(docConf)
+	selConv := NewContextSelectionConverter(c.SelectionConv)
+
+	matched := selConv.FindContentElements(elm)
+
+	// Elements that are not matched are handled by the SelectionConverter if it can,
+	// and are otherwise left out along with any loose text
+	unknownConv, handlesUnknown := c.SelectionConv.(UnknownElementConverter)
+	var unknown []*html.Node
+	for _, node := range unconvertedChildren(elm, matched) {
+		if handlesUnknown && node.Type == html.ElementNode {
+			unknown = append(unknown, node)
+			continue
+		}
+		c.Diagnostics.addSkipped(node)
+		if policyFails(c.ElementPolicy, node) {
+			return mdDoc, unsupportedError(node, skippedMessage(node))
+		}
+	}
+
+	for _, item := range contentItems(elm, matched, unknown) {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return mdDoc, newConversionError(item.elm, ctxErr)
+		}
 
-	c.SelectionConv.FindContentElements(elm).Each(func(i int, elm *goquery.Selection) {
-		c.SelectionConv.HandleMatchedSelection(i, elm, mdDoc, c.SelectionToMarkdown)
+		blocks := mdDoc.Len()
+		if item.index < 0 {
+			if handleErr := unknownConv.HandleUnknownSelection(item.elm, mdDoc); handleErr != nil {
+				return mdDoc, newConversionError(item.elm, handleErr)
+			}
+			setPositions(ctx, mdDoc, blocks, item.elm)
+			continue
+		}
+
+		if handleErr := selConv.HandleMatchedSelectionContext(ctx, item.index, item.elm, mdDoc, c.SelectionToMarkdownContext); handleErr != nil {
+			return mdDoc, newConversionError(item.elm, handleErr)
+		}
+		setPositions(ctx, mdDoc, blocks, item.elm)
+		if mdDoc.Len() == blocks && hasContent(item.elm.Nodes[0]) {
+			message := fmt.Sprintf("<%s> has content, but no markdown was added for it", goquery.NodeName(item.elm))
+			c.Diagnostics.addNode(DiagnosticSkipped, item.elm.Nodes[0], message)
+			if policyFails(c.ElementPolicy, item.elm.Nodes[0]) {
+				return mdDoc, unsupportedError(item.elm.Nodes[0], message)
+			}
+		}
+	}
+
+	return mdDoc, nil
+}
+
+// setPositions sets the position of the blocks added for the element from the index,
+// if the context has the source lines of the document
+func setPositions(ctx context.Context, mdDoc *markdown.Doc, from int, elm *goquery.Selection) {
+	if pos, exists := sourcePosition(ctx, elm.Nodes[0]); exists {
+		mdDoc.SetPositions(from, pos)
+	}
+}
+
+// contentItem is an element to convert. Index is its index in the matched selection,
+// or -1 if it is not matched.
+type contentItem struct {
+	index int
+	elm   *goquery.Selection
+}
+
+// contentItems orders the matched elements and the unknown elements to convert.
+// The matched elements keep their order, and each unknown element is placed before
+// the first matched element that comes after it in the document.
+func contentItems(elm *goquery.Selection, matched *goquery.Selection, unknown []*html.Node) []contentItem {
+	items := make([]contentItem, 0, matched.Length()+len(unknown))
+	if len(unknown) == 0 {
+		matched.Each(func(i int, s *goquery.Selection) {
+			items = append(items, contentItem{index: i, elm: s})
+		})
+		return items
+	}
+
+	positions := map[*html.Node]int{}
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		positions[node] = len(positions)
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, node := range elm.Nodes {
+		walk(node)
+	}
+
+	next := 0
+	matched.Each(func(i int, s *goquery.Selection) {
+		for ; next < len(unknown) && positions[unknown[next]] < positions[s.Nodes[0]]; next++ {
+			items = append(items, contentItem{index: -1, elm: elm.FindNodes(unknown[next])})
+		}
+		items = append(items, contentItem{index: i, elm: s})
 	})
+	for ; next < len(unknown); next++ {
+		items = append(items, contentItem{index: -1, elm: elm.FindNodes(unknown[next])})
+	}
+	return items
+}
 
-	return mdDoc
+// contextSelectionConverter adapts a SelectionConverter to a ContextSelectionConverter
+type contextSelectionConverter struct {
+	SelectionConverter
+}
+
+// NewContextSelectionConverter adapts the SelectionConverter to a ContextSelectionConverter.
+// The SelectionConverter is returned as is if it already is a ContextSelectionConverter.
+// Otherwise, errors from converting child elements are returned after its handler is done,
+// and a panic in its handler is returned as an error.
+func NewContextSelectionConverter(selectionConv SelectionConverter) ContextSelectionConverter {
+	if contextConv, ok := selectionConv.(ContextSelectionConverter); ok {
+		return contextConv
+	}
+	return contextSelectionConverter{selectionConv}
+}
+
+// HandleMatchedSelectionContext calls HandleMatchedSelection, with a SelectionToMD that
+// records the first error from converting child elements.
+func (c contextSelectionConverter) HandleMatchedSelectionContext(ctx context.Context, i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMDContext) error {
+	return withContext(c.HandleMatchedSelection)(ctx, i, elm, mdDoc, toMD)
+}
+
+// withContext adapts the HandleSelection to a HandleSelectionContext. The first error from
+// converting child elements is returned after the handler is done, and a panic in the
+// handler is returned as an error.
+func withContext(handler HandleSelection) HandleSelectionContext {
+	return func(ctx context.Context, i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMDContext) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+
+		handler(i, elm, mdDoc, func(child *goquery.Selection, docConf markdown.DocConfig) *markdown.Doc {
+			childDoc, childErr := toMD(ctx, child, docConf)
+			if childErr != nil && err == nil {
+				err = childErr
+			}
+			return childDoc
+		})
+
+		return
+	}
+}
+
+// withoutContext adapts the HandleSelectionContext to a HandleSelection, which ignores any errors
+func withoutContext(handler HandleSelectionContext) HandleSelection {
+	return func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		_ = handler(context.Background(), i, elm, mdDoc, func(_ context.Context, child *goquery.Selection, docConf markdown.DocConfig) (*markdown.Doc, error) {
+			return toMD(child, docConf), nil
+		})
+	}
 }
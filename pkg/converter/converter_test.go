@@ -1,6 +1,8 @@
 package converter
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/PuerkitoBio/goquery"
@@ -96,3 +98,110 @@ func TestDocumentToMarkdownWithUnicode(t *testing.T) {
 		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
 	}
 }
+
+type PanicSelectionConverter struct {
+	TestSelectionConverter
+}
+
+func (c *PanicSelectionConverter) FindContentElements(s *goquery.Selection) *goquery.Selection {
+	return s.ChildrenFiltered("div,p")
+}
+
+func (c *PanicSelectionConverter) HandleMatchedSelection(i int, s *goquery.Selection, md *markdown.Doc, toMD SelectionToMD) {
+	if goquery.NodeName(s) == "div" {
+		md.AddDoc(toMD(s, md.GetRenderConfig()))
+		return
+	}
+	if s.HasClass("bad") {
+		panic("bad paragraph")
+	}
+	md.AddParagraph(s.Text())
+}
+
+type ErrorSelectionConverter struct {
+	TestSelectionConverter
+}
+
+func (c *ErrorSelectionConverter) HandleMatchedSelectionContext(ctx context.Context, i int, s *goquery.Selection, md *markdown.Doc, toMD SelectionToMDContext) error {
+	if s.HasClass("bad") {
+		return errors.New("bad paragraph")
+	}
+	md.AddParagraph(s.Text())
+	return nil
+}
+
+func TestDocumentToMarkdownContextWithPanic(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<body>
+		<div id="content">
+			<p>Paragraph 1</p>
+			<p>Paragraph 2</p>
+			<p class="bad">Paragraph 3</p>
+		</div>
+	</body>
+</html>
+`)
+	c := NewDocumentConverter(&PanicSelectionConverter{TestSelectionConverter{Transformer: NewTransformer(nil)}}, nil)
+
+	mdDoc, err := c.DocumentToMarkdownContext(context.Background(), doc)
+
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("Expected a ConversionError. Got %v", err)
+	}
+	expected := "html > body > div#content > p:nth-of-type(3)"
+	if convErr.Path != expected {
+		t.Errorf("Expected %s. Got %s", expected, convErr.Path)
+	}
+	expected = "Paragraph 1\n\nParagraph 2"
+	if mdDoc.Content() != expected {
+		t.Errorf("Expected %s. Got %s", expected, mdDoc.Content())
+	}
+
+	// The original method ignores the error
+	expected = "Paragraph 1\n\nParagraph 2"
+	if result := c.DocumentToMarkdown(doc).Content(); result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestDocumentToMarkdownContextWithError(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<body>
+		<h1>Title</h1>
+		<p>Paragraph 1</p>
+		<p class="bad">Paragraph 2</p>
+	</body>
+</html>
+`)
+	c := NewDocumentConverter(&ErrorSelectionConverter{TestSelectionConverter{Transformer: NewTransformer(nil)}}, nil)
+
+	_, err := c.DocumentToMarkdownContext(context.Background(), doc)
+
+	expected := "converting html > body > p:nth-of-type(2): bad paragraph"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %s. Got %v", expected, err)
+	}
+}
+
+func TestDocumentToMarkdownContextCancelled(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<body>
+		<h1>Title</h1>
+		<p>Paragraph 1</p>
+	</body>
+</html>
+`)
+	c := NewDocumentConverter(&TestSelectionConverter{Transformer: NewTransformer(nil)}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.DocumentToMarkdownContext(ctx, doc)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %s. Got %v", context.Canceled, err)
+	}
+}
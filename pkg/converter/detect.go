@@ -0,0 +1,129 @@
+package converter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// InputAuto detects the input format of each document with DetectFormat
+	InputAuto = "auto"
+	// InputWord is the format of Microsoft Word documents saved as HTML.
+	// It is detected, but converted as "html".
+	InputWord = "word"
+
+	// minDetectConfidence is the confidence below which a document is detected as "html"
+	minDetectConfidence = 0.3
+)
+
+// detectSignal is a marker of a format in a document. Weight is how much the
+// marker adds to the confidence that the document is in the format.
+type detectSignal struct {
+	format string
+	name   string
+	weight float64
+	found  func(*goquery.Document) bool
+}
+
+// googleClassPattern matches the generated classes of Google Docs, such as "c0" and "c12"
+var googleClassPattern = regexp.MustCompile(`(^|\s)c\d+(\s|$)`)
+
+var detectSignals = []detectSignal{
+	{InputConfluence, "#main-content", 0.35, selectorFound("#main-content")},
+	{InputConfluence, "#title-text", 0.35, selectorFound("#title-text")},
+	{InputConfluence, ".confluence-information-macro", 0.3, selectorFound(".confluence-information-macro")},
+	{InputConfluence, ".page-metadata", 0.2, selectorFound(".page-metadata, #page-metadata")},
+	{InputGoogle, "docs-internal-guid", 0.5, selectorFound(`[id^="docs-internal-guid"]`)},
+	{InputGoogle, "c0-style classes", 0.4, func(doc *goquery.Document) bool {
+		return doc.Find("[class]").FilterFunction(func(i int, s *goquery.Selection) bool {
+			class, _ := s.Attr("class")
+			return googleClassPattern.MatchString(class)
+		}).Length() >= 3
+	}},
+	{InputGoogle, "lst-kix lists", 0.2, selectorFound(`[class*="lst-kix_"]`)},
+	{InputWord, "MsoNormal", 0.5, selectorFound(".MsoNormal")},
+	{InputWord, "Microsoft Word generator", 0.4, selectorFound(`meta[name="Generator"][content*="Microsoft Word"], meta[name="ProgId"][content^="Word."]`)},
+	{InputWord, "office namespaces", 0.2, selectorFound(`html[xmlns\:w], html[xmlns\:o]`)},
+}
+
+// selectorFound creates a check for whether any element matches the selector
+func selectorFound(selector string) func(*goquery.Document) bool {
+	return func(doc *goquery.Document) bool {
+		return doc.Find(selector).Length() > 0
+	}
+}
+
+// Detection is the format a document was detected to be in. Confidence is between 0 and 1.
+// Signals are the markers of the format that were found in the document.
+type Detection struct {
+	Format     string   `json:"format"`
+	Confidence float64  `json:"confidence"`
+	Signals    []string `json:"signals"`
+}
+
+// String describes the detection, such as "confluence (95%)"
+func (d Detection) String() string {
+	return fmt.Sprintf("%s (%.0f%%)", d.Format, d.Confidence*100)
+}
+
+// InputFormat is the input format to convert the document with. Formats that do not have
+// their own converter, such as "word", are converted as "html".
+func (d Detection) InputFormat() string {
+	if d.Format == InputWord {
+		return InputHTML
+	}
+	return d.Format
+}
+
+// DetectFormat detects whether the document is a Confluence page, a Google Doc, a Word document,
+// or any other HTML, by the markers each one leaves in its HTML export.
+// The format with the most confidence is used, unless no format has enough confidence,
+// in which case the document is "html".
+func DetectFormat(doc *goquery.Document) Detection {
+	confidence := map[string]float64{}
+	signals := map[string][]string{}
+	for _, signal := range detectSignals {
+		if signal.found(doc) {
+			confidence[signal.format] += signal.weight
+			signals[signal.format] = append(signals[signal.format], signal.name)
+		}
+	}
+
+	best := Detection{Format: InputHTML}
+	for _, format := range []string{InputConfluence, InputGoogle, InputWord} {
+		if confidence[format] > best.Confidence {
+			best = Detection{Format: format, Confidence: math.Min(confidence[format], 1), Signals: signals[format]}
+		}
+	}
+
+	if best.Confidence < minDetectConfidence {
+		return Detection{Format: InputHTML, Confidence: 1 - best.Confidence}
+	}
+	return best
+}
+
+// DetectReader detects the format of the HTML read from the reader
+func DetectReader(r io.Reader) (Detection, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Detection{}, err
+	}
+	return DetectFormat(doc), nil
+}
+
+// newAutoSelectionConverter creates a FindSelectionConverter that chooses between the SelectionConverters
+// for each input format, by the detected format of the document
+func newAutoSelectionConverter(conf SelectionConverterConfig) FindSelectionConverter {
+	converters := map[string]SelectionConverter{
+		InputHTML:       NewHTMLSelectionConverter(conf),
+		InputConfluence: NewConfluenceSelectionConverter(conf),
+		InputGoogle:     NewGoogleSelectionConverter(conf),
+	}
+	return func(doc *goquery.Document) SelectionConverter {
+		return converters[DetectFormat(doc).InputFormat()]
+	}
+}
@@ -0,0 +1,89 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		content string
+		format  string
+	}{
+		{
+			`<html><body><span id="title-text">Title</span><div id="main-content"><p>Text</p></div></body></html>`,
+			InputConfluence,
+		},
+		{
+			`<html><body><p class="c3"><span class="c0" id="docs-internal-guid-1234">Text</span><span class="c1">More</span></p></body></html>`,
+			InputGoogle,
+		},
+		{
+			`<html xmlns:w="urn:schemas-microsoft-com:office:word"><head><meta name="Generator" content="Microsoft Word 15"></head><body><p class="MsoNormal">Text</p></body></html>`,
+			InputWord,
+		},
+		{
+			`<html><body><div id="content"><p class="c0">Text</p></div></body></html>`,
+			InputHTML,
+		},
+	}
+
+	for _, test := range tests {
+		result := DetectFormat(newTestDoc(test.content))
+		if result.Format != test.format {
+			t.Errorf("Expected %s. Got %s", test.format, result)
+		}
+		if result.Confidence < minDetectConfidence || result.Confidence > 1 {
+			t.Errorf("Expected a confidence between %f and 1. Got %f", minDetectConfidence, result.Confidence)
+		}
+	}
+}
+
+func TestDetectFormatSignals(t *testing.T) {
+	result := DetectFormat(newTestDoc(`<html xmlns:o="urn:schemas-microsoft-com:office:office"><body><p class="MsoNormal">Text</p></body></html>`))
+
+	expected := "word (70%)"
+	if result.String() != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+	if len(result.Signals) != 2 || result.Signals[0] != "MsoNormal" || result.Signals[1] != "office namespaces" {
+		t.Errorf("Expected %s. Got %s", "[MsoNormal office namespaces]", result.Signals)
+	}
+	if result.InputFormat() != InputHTML {
+		t.Errorf("Expected %s. Got %s", InputHTML, result.InputFormat())
+	}
+}
+
+func TestConvertStringAutoFormat(t *testing.T) {
+	content := `
+<html>
+	<head>
+		<title>Test Doc</title>
+	</head>
+	<body>
+		<p>Ignored Content</p>
+		<span id="title-text">Confluence Title</span>
+		<div id="main-content">
+			<p>Confluence Paragraph</p>
+		</div>
+	</body>
+</html>
+`
+
+	result, err := ConvertString(content, WithInputFormat(InputAuto))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	expected := "# Confluence Title\n\nConfluence Paragraph\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+
+	result, err = ConvertString(`<html><head><title>Test Doc</title></head><body><p>Paragraph</p></body></html>`, WithInputFormat(InputAuto))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	expected = "# Test Doc\n\nParagraph\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
@@ -0,0 +1,281 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// DiagnosticKind is the kind of problem a Diagnostic describes
+type DiagnosticKind string
+
+const (
+	// DiagnosticSkipped is content that is left out of the markdown
+	DiagnosticSkipped DiagnosticKind = "skipped"
+	// DiagnosticLossy is content that is converted, but loses some of its formatting or structure
+	DiagnosticLossy DiagnosticKind = "lossy"
+)
+
+// sourceLineLookahead limits how far ahead in the source an element is searched for,
+// since elements that are added by the parser, such as "tbody", are not in the source.
+const sourceLineLookahead = 16
+
+var (
+	// ignoredTags are elements that are not content, so are not reported when skipped
+	ignoredTags = map[string]bool{
+		"head": true, "title": true, "meta": true, "link": true, "script": true, "style": true,
+		"noscript": true, "template": true, "base": true,
+	}
+
+	// mediaTags are elements that are content even when they do not contain text
+	mediaTags = map[string]bool{
+		"img": true, "iframe": true, "video": true, "audio": true, "object": true, "embed": true,
+		"svg": true, "canvas": true, "picture": true,
+	}
+
+	// unsupportedInlineTags are inline elements whose formatting has no markdown equivalent
+	unsupportedInlineTags = map[string]bool{
+		"u": true, "ins": true, "mark": true, "sup": true, "sub": true, "small": true, "big": true,
+		"font": true, "abbr": true, "q": true, "cite": true, "dfn": true, "var": true,
+	}
+
+	// styledTextPattern matches styles that format text, which are ignored
+	styledTextPattern = regexp.MustCompile(`(?i)font-weight\s*:\s*(bold|[6-9]00)|font-style\s*:\s*italic|text-decoration[^;]*(underline|line-through)`)
+)
+
+// Diagnostic describes content that could not be fully converted to markdown.
+// Path identifies the element as a CSS selector, and Line is its line in the
+// HTML source, or 0 if the line is not known.
+type Diagnostic struct {
+	Kind    DiagnosticKind `json:"kind"`
+	Element string         `json:"element"`
+	Path    string         `json:"path"`
+	Line    int            `json:"line,omitempty"`
+	Message string         `json:"message"`
+}
+
+// String describes the diagnostic on a single line
+func (d Diagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("line %d: %s %s: %s", d.Line, d.Kind, d.Path, d.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", d.Kind, d.Path, d.Message)
+}
+
+// Diagnostics collects the content that could not be fully converted to markdown.
+// It is safe to use from multiple goroutines, but is usually used for a single document,
+// since it only knows the source lines of the documents it has indexed.
+type Diagnostics struct {
+	mu          sync.Mutex
+	diagnostics []Diagnostic
+	lines       map[*html.Node]int
+}
+
+// NewDiagnostics creates an empty Diagnostics
+func NewDiagnostics() *Diagnostics {
+	return &Diagnostics{lines: map[*html.Node]int{}}
+}
+
+// IndexSource finds the line of each element of the document in its HTML source,
+// so that diagnostics can include the line of the element.
+func (d *Diagnostics) IndexSource(source []byte, doc *goquery.Document) {
+	d.indexLines(sourceLines(source, doc))
+}
+
+// indexLines adds the lines of the elements found by sourceLines.
+// It does nothing if d is nil.
+func (d *Diagnostics) indexLines(lines map[*html.Node]int) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for node, line := range lines {
+		d.lines[node] = line
+	}
+}
+
+// Add records a diagnostic for the first element in the selection
+func (d *Diagnostics) Add(kind DiagnosticKind, elm *goquery.Selection, message string) {
+	if len(elm.Nodes) > 0 {
+		d.addNode(kind, elm.Nodes[0], message)
+	}
+}
+
+// All gets the diagnostics in the order they were recorded
+func (d *Diagnostics) All() []Diagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Diagnostic(nil), d.diagnostics...)
+}
+
+// Len is the number of diagnostics recorded
+func (d *Diagnostics) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.diagnostics)
+}
+
+// addNode records a diagnostic for the node. It does nothing if d is nil,
+// so callers do not need to check whether diagnostics are enabled.
+func (d *Diagnostics) addNode(kind DiagnosticKind, node *html.Node, message string) {
+	if d == nil || node == nil {
+		return
+	}
+
+	diagnostic := Diagnostic{Kind: kind, Element: node.Data, Path: nodePath(node), Message: message}
+	if node.Type == html.TextNode {
+		diagnostic.Element = "#text"
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	diagnostic.Line = nodeLine(d.lines, node)
+	d.diagnostics = append(d.diagnostics, diagnostic)
+}
+
+// nodeLine gets the line of the node, or of its closest ancestor with a known line
+func nodeLine(lines map[*html.Node]int, node *html.Node) int {
+	for n := node; n != nil; n = n.Parent {
+		if line := lines[n]; line > 0 {
+			return line
+		}
+	}
+	return 0
+}
+
+// sourceLinesKey is the key of the source lines of the document being converted in a context
+type sourceLinesKey struct{}
+
+// withSourceLines adds the source lines of the document to the context, so that
+// each block converted from the document gets the position of its element
+func withSourceLines(ctx context.Context, lines map[*html.Node]int) context.Context {
+	return context.WithValue(ctx, sourceLinesKey{}, lines)
+}
+
+// sourcePosition gets the position of the element, if the context has the source lines of its document
+func sourcePosition(ctx context.Context, node *html.Node) (markdown.Position, bool) {
+	lines, exists := ctx.Value(sourceLinesKey{}).(map[*html.Node]int)
+	if !exists {
+		return markdown.Position{}, false
+	}
+	return markdown.Position{Line: nodeLine(lines, node), Path: nodePath(node)}, true
+}
+
+// addSkipped records that the node is left out of the markdown
+func (d *Diagnostics) addSkipped(node *html.Node) {
+	d.addNode(DiagnosticSkipped, node, skippedMessage(node))
+}
+
+// skippedMessage describes that the node is left out of the markdown
+func skippedMessage(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return "text outside of any converted element is left out"
+	}
+	return fmt.Sprintf("<%s> is not converted", node.Data)
+}
+
+// unconvertedChildren finds the children of the element that have content, but are not
+// in the matched selection and do not contain any of it, so are not converted.
+func unconvertedChildren(elm *goquery.Selection, matched *goquery.Selection) []*html.Node {
+	// The matched elements and their ancestors are all converted, at least in part
+	converted := map[*html.Node]bool{}
+	for _, node := range matched.Nodes {
+		for n := node; n != nil && !converted[n]; n = n.Parent {
+			converted[n] = true
+		}
+	}
+
+	var children []*html.Node
+	for _, node := range elm.Nodes {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if !converted[child] && (child.Type == html.TextNode || child.Type == html.ElementNode) && hasContent(child) {
+				children = append(children, child)
+			}
+		}
+	}
+	return children
+}
+
+// hasContent checks whether the node contains any text or media
+func hasContent(node *html.Node) bool {
+	switch node.Type {
+	case html.TextNode:
+		return strings.TrimSpace(node.Data) != ""
+	case html.ElementNode:
+		if ignoredTags[node.Data] {
+			return false
+		}
+		if mediaTags[node.Data] {
+			return true
+		}
+	default:
+		return false
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if hasContent(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLines finds the line in the source of each element in the document. The start tags
+// in the source are matched in order to the elements in the document, skipping elements that
+// the parser added which are not in the source.
+func sourceLines(source []byte, doc *goquery.Document) map[*html.Node]int {
+	type startTag struct {
+		name string
+		line int
+	}
+
+	var tags []startTag
+	tokenizer := html.NewTokenizer(bytes.NewReader(source))
+	line := 1
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		raw := tokenizer.Raw()
+		newlines := bytes.Count(raw, []byte("\n"))
+		if tokenType == html.StartTagToken || tokenType == html.SelfClosingTagToken {
+			name, _ := tokenizer.TagName()
+			tags = append(tags, startTag{name: string(name), line: line})
+		}
+		line += newlines
+	}
+
+	lines := map[*html.Node]int{}
+	next := 0
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			for idx := next; idx < len(tags) && idx < next+sourceLineLookahead; idx++ {
+				if tags[idx].name == node.Data {
+					lines[node] = tags[idx].line
+					next = idx + 1
+					break
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, node := range doc.Nodes {
+		walk(node)
+	}
+
+	return lines
+}
@@ -0,0 +1,49 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	content := `<html>
+<head><title>Page Title</title></head>
+<body>
+	<dl>
+		<dd>Left out</dd>
+	</dl>
+	<p>Some <sup>2</sup> text</p>
+	<table>
+		<tr><th colspan="2">Header</th></tr>
+		<tr><td>a</td><td>b</td></tr>
+	</table>
+	<script>ignored()</script>
+</body>
+</html>`
+
+	diagnostics := NewDiagnostics()
+	if _, err := ConvertString(content, WithDiagnostics(diagnostics)); err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := []Diagnostic{
+		{Kind: DiagnosticSkipped, Element: "dl", Path: "html > body > dl", Line: 4, Message: "<dl> is not converted"},
+		{Kind: DiagnosticLossy, Element: "sup", Path: "html > body > p > sup", Line: 7, Message: "markdown has no formatting like <sup>, so only its text is kept"},
+		{Kind: DiagnosticLossy, Element: "th", Path: "html > body > table > tbody > tr:nth-of-type(1) > th", Line: 9, Message: "the cell spans 2 columns and 1 rows, which are expanded into separate cells"},
+	}
+	result := diagnostics.All()
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d diagnostics. Got %d: %v", len(expected), len(result), result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("Expected %s. Got %s", expected[i], result[i])
+		}
+	}
+}
+
+func TestDiagnosticsDisabled(t *testing.T) {
+	var diagnostics *Diagnostics
+	node := newTestDoc("<p>text</p>").Find("p").Nodes[0]
+	diagnostics.addNode(DiagnosticSkipped, node, "message")
+	diagnostics.addSkipped(node)
+}
@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// ConversionError is an error converting an element of an HTML document.
+// Path identifies the element, such as "html > body > div#content > table:nth-of-type(2)".
+type ConversionError struct {
+	Path string
+	Err  error
+}
+
+// Error describes the error and the element it occurred in
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("converting %s: %s", e.Path, e.Err)
+}
+
+// Unwrap gets the underlying error
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// newConversionError wraps the error with the path of the element it occurred in.
+// An error that is already a ConversionError is returned as is, since it
+// identifies the element more precisely than any of its parents.
+func newConversionError(elm *goquery.Selection, err error) error {
+	var convErr *ConversionError
+	if errors.As(err, &convErr) {
+		return err
+	}
+	return &ConversionError{Path: ElementPath(elm), Err: err}
+}
+
+// ElementPath describes the location of the first element in the selection as a CSS selector.
+// Each element in the path is identified by its id if it has one, and otherwise by its
+// position among the siblings with the same tag if there is more than one.
+func ElementPath(elm *goquery.Selection) string {
+	if len(elm.Nodes) == 0 {
+		return ""
+	}
+	return nodePath(elm.Nodes[0])
+}
+
+// nodePath describes the location of the node as a CSS selector.
+// A text node is described as "#text" within its parent.
+func nodePath(node *html.Node) string {
+	var parts []string
+	if node.Type == html.TextNode {
+		parts = append(parts, "#text")
+		node = node.Parent
+	}
+	for ; node != nil && node.Type == html.ElementNode; node = node.Parent {
+		parts = append([]string{nodeSelector(node)}, parts...)
+	}
+	return strings.Join(parts, " > ")
+}
+
+// nodeSelector describes the node as a CSS selector among its siblings
+func nodeSelector(node *html.Node) string {
+	if id, _ := nodeAttr(node, "id"); id != "" {
+		return node.Data + "#" + id
+	}
+	if node.Parent == nil {
+		return node.Data
+	}
+
+	position, count := 0, 0
+	for sibling := node.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode && sibling.Data == node.Data {
+			count++
+			if sibling == node {
+				position = count
+			}
+		}
+	}
+	if count > 1 {
+		return fmt.Sprintf("%s:nth-of-type(%d)", node.Data, position)
+	}
+	return node.Data
+}
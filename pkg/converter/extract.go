@@ -0,0 +1,158 @@
+package converter
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+const (
+	// minParagraphLength is the length of text below which a paragraph does not count towards the score
+	minParagraphLength = 25
+	// hintWeight is added to, or subtracted from, the score of an element whose class or id
+	// hints that it is, or is not, the main content
+	hintWeight = 25
+)
+
+var (
+	// positiveHintPattern matches the classes and ids of elements that are likely the main content
+	positiveHintPattern = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story`)
+	// negativeHintPattern matches the classes and ids of elements that are likely not the main content
+	negativeHintPattern = regexp.MustCompile(`(?i)comment|foot|nav|menu|sidebar|banner|cookie|consent|share|social|related|promo|sponsor|popup|modal|widget|breadcrumb|masthead|(^|[-_ ])ads?([-_ ]|$)`)
+	// hiddenStylePattern matches styles that hide an element
+	hiddenStylePattern = regexp.MustCompile(`(?i)display\s*:\s*none|visibility\s*:\s*hidden`)
+
+	// tagWeights are added to the score of elements with the tag
+	tagWeights = map[string]float64{
+		"article": 10, "main": 10, "section": 5, "div": 5, "td": 3, "blockquote": 3, "pre": 3,
+		"form": -3, "ul": -3, "ol": -3, "header": -5, "footer": -5, "aside": -5, "nav": -10,
+	}
+
+	// paragraphPattern matches the elements whose text is scored
+	paragraphPattern = "p,pre,td,blockquote,div,section"
+	// blockPattern matches the elements that make a "div" or "section" a container rather than a paragraph
+	blockPattern = "p,pre,table,blockquote,ul,ol,dl,div,section,article,h1,h2,h3,h4,h5,h6,form"
+)
+
+// FindMainContent finds the element with the main content of a web page, leaving out menus,
+// banners, sidebars, and footers. Elements are scored by the length of their paragraphs,
+// how much of their text is links, and whether their tag, class, or id hint that they are
+// the main content. The "body" is used if no element has enough text to score.
+// It can be used as the RootElementFinder of any SelectionConverter.
+func FindMainContent(doc *goquery.Document) *goquery.Selection {
+	body := doc.Find("body").First()
+
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode {
+			return
+		}
+		if _, exists := scores[node]; !exists {
+			scores[node] = initialScore(node)
+			candidates = append(candidates, node)
+		}
+		scores[node] += score
+	}
+
+	body.Find(paragraphPattern).Each(func(i int, s *goquery.Selection) {
+		node := s.Nodes[0]
+		if isHidden(node) || (node.Data == "div" || node.Data == "section") && s.ChildrenFiltered(blockPattern).Length() > 0 {
+			return
+		}
+		text := strings.TrimSpace(s.Text())
+		if len(text) < minParagraphLength {
+			return
+		}
+
+		// Longer paragraphs and paragraphs with more commas are more likely to be prose
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		addScore(node.Parent, score)
+		if node.Parent != nil {
+			addScore(node.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, node := range candidates {
+		score := scores[node] * (1 - linkDensity(node))
+		if best == nil || score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+
+	if best == nil || bestScore <= 0 {
+		return body
+	}
+	return doc.FindNodes(best)
+}
+
+// initialScore scores the element by its tag, class, and id
+func initialScore(node *html.Node) float64 {
+	score := tagWeights[node.Data]
+	for _, key := range []string{"class", "id"} {
+		value, _ := nodeAttr(node, key)
+		if value == "" {
+			continue
+		}
+		if negativeHintPattern.MatchString(value) {
+			score -= hintWeight
+		}
+		if positiveHintPattern.MatchString(value) {
+			score += hintWeight
+		}
+	}
+	if role, _ := nodeAttr(node, "role"); role == "main" {
+		score += hintWeight
+	}
+	return score
+}
+
+// linkDensity is the fraction of the text of the node that is in links
+func linkDensity(node *html.Node) float64 {
+	var textLength, linkLength int
+	var walk func(*html.Node, bool)
+	walk = func(n *html.Node, inLink bool) {
+		if n.Type == html.TextNode {
+			length := len(strings.TrimSpace(n.Data))
+			textLength += length
+			if inLink {
+				linkLength += length
+			}
+			return
+		}
+		inLink = inLink || n.Type == html.ElementNode && n.Data == "a"
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, inLink)
+		}
+	}
+	walk(node, false)
+
+	if textLength == 0 {
+		return 0
+	}
+	return float64(linkLength) / float64(textLength)
+}
+
+// isHidden checks whether the node, or any of its ancestors, is hidden
+func isHidden(node *html.Node) bool {
+	for n := node; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if _, hidden := nodeAttr(n, "hidden"); hidden {
+			return true
+		}
+		if value, _ := nodeAttr(n, "aria-hidden"); value == "true" {
+			return true
+		}
+		if style, _ := nodeAttr(n, "style"); hiddenStylePattern.MatchString(style) {
+			return true
+		}
+	}
+	return false
+}
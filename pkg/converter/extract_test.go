@@ -0,0 +1,74 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const extractTestHTML = `
+<html>
+	<head>
+		<title>Page Title</title>
+	</head>
+	<body>
+		<div id="cookie-banner">
+			<p>We use cookies to improve your experience, measure traffic, and show you relevant offers.</p>
+		</div>
+		<div class="menu">
+			<ul>
+				<li><a href="/">Home</a></li>
+				<li><a href="/blog">Blog</a></li>
+				<li><a href="/about">About</a></li>
+			</ul>
+		</div>
+		<div class="layout">
+			<div class="post-content">
+				<h1>Article Header</h1>
+				<p>The first paragraph of the article, which has quite a lot of text, and a few commas, in it.</p>
+				<p>The second paragraph of the article is also long, so that it clearly is the main content.</p>
+			</div>
+			<div class="sidebar">
+				<p><a href="/related-1">A related article with a long title about something else</a></p>
+				<p><a href="/related-2">Another related article with an even longer title</a></p>
+			</div>
+		</div>
+		<footer>
+			<p>Copyright 2020, Example Company, Inc. All rights reserved.</p>
+		</footer>
+	</body>
+</html>
+`
+
+func TestFindMainContent(t *testing.T) {
+	doc := newTestDoc(extractTestHTML)
+
+	result := FindMainContent(doc)
+	if class, _ := result.Attr("class"); class != "post-content" {
+		t.Errorf("Expected %s. Got %s", "post-content", class)
+	}
+}
+
+func TestFindMainContentWithoutParagraphs(t *testing.T) {
+	doc := newTestDoc(`<html><body><span>Short</span></body></html>`)
+
+	result := FindMainContent(doc)
+	if name := goquery.NodeName(result); name != "body" {
+		t.Errorf("Expected %s. Got %s", "body", name)
+	}
+}
+
+func TestConvertStringExtractMain(t *testing.T) {
+	result, err := ConvertString(extractTestHTML, WithExtractMain(true))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "# Page Title\n\n" +
+		"## Article Header\n\n" +
+		"The first paragraph of the article, which has quite a lot of text, and a few commas, in it.\n\n" +
+		"The second paragraph of the article is also long, so that it clearly is the main content.\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
@@ -27,6 +27,9 @@ func TestDefaultGoogleConverter(t *testing.T) {
 				<li>Item 2</li>
 			</ol>
 		</div>
+		<blockquote>
+			<p>Quote</p>
+		</blockquote>
 		<hr style="page-break-after: auto">
 		<table>
 			<tr>
@@ -66,6 +69,8 @@ Test Paragraph 3
 1. Item 1
 1. Item 2
 
+> Quote
+
 | Column 1 | Column 2 |
 | --- | --- |
 | Data 1 | Data 2 |`
@@ -74,3 +79,17 @@ Test Paragraph 3
 		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
 	}
 }
+
+func TestGoogleConverterBlockquoteWithLooseText(t *testing.T) {
+	doc := newTestDoc(`<html><body><blockquote>Loose <b>text</b><p>inner</p> tail<ul><li>item</li></ul>end</blockquote></body></html>`)
+
+	s := NewGoogleSelectionConverter(SelectionConverterConfig{})
+	c := NewDocumentConverter(s, nil)
+
+	result := c.DocumentToMarkdown(doc).Content()
+	expected := "> Loose **text**\n>\n> inner\n>\n> tail\n>\n> * item\n>\n> end"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
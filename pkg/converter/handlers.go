@@ -0,0 +1,318 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+const (
+	// PriorityDefault is the priority of the handlers for standard HTML elements
+	PriorityDefault = 0
+	// PriorityConverter is the priority of the handlers for elements specific to
+	// a kind of document, such as Confluence panels
+	PriorityConverter = 100
+	// PriorityCustom is a priority for custom handlers that should override any provided handler
+	PriorityCustom = 1000
+)
+
+// HandlerRegistry holds the handlers that convert elements matched by a tag or CSS selector.
+// An element is converted by the matching handler with the highest priority.
+// If more than one matching handler has the same priority, the last one registered is used.
+// Handlers registered with HandleContext can return an error, which stops the conversion.
+type HandlerRegistry struct {
+	handlers []registeredHandler
+	next     int
+}
+
+type registeredHandler struct {
+	selector       string
+	matcher        cascadia.SelectorGroup
+	priority       int
+	order          int
+	handler        HandleSelection
+	contextHandler HandleSelectionContext
+}
+
+// NewHandlerRegistry creates an empty HandlerRegistry
+func NewHandlerRegistry() *HandlerRegistry {
+	return &HandlerRegistry{}
+}
+
+// Handle registers the handler for elements matching the CSS selector, such as "p", "h1,h2", or "div.callout".
+// A panic in the handler is returned as an error by HandleSelectionContext.
+func (r *HandlerRegistry) Handle(selector string, priority int, handler HandleSelection) error {
+	return r.add(registeredHandler{selector: selector, priority: priority, handler: handler, contextHandler: withContext(handler)})
+}
+
+// HandleContext registers the handler for elements matching the CSS selector, like Handle.
+// An error from the handler is returned by HandleSelectionContext, and is ignored by HandleSelection.
+func (r *HandlerRegistry) HandleContext(selector string, priority int, handler HandleSelectionContext) error {
+	return r.add(registeredHandler{selector: selector, priority: priority, handler: withoutContext(handler), contextHandler: handler})
+}
+
+// add registers the handler after the handlers that are already registered
+func (r *HandlerRegistry) add(h registeredHandler) error {
+	matcher, err := cascadia.ParseGroup(h.selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector %q: %w", h.selector, err)
+	}
+
+	h.matcher = matcher
+	h.order = r.next
+	r.handlers = append(r.handlers, h)
+	r.next++
+	// Keep the handlers sorted so the first match is the one to use
+	sort.SliceStable(r.handlers, func(i, j int) bool {
+		if r.handlers[i].priority != r.handlers[j].priority {
+			return r.handlers[i].priority > r.handlers[j].priority
+		}
+		return r.handlers[i].order > r.handlers[j].order
+	})
+	return nil
+}
+
+// mustHandle registers a handler with a selector that is known to be valid
+func (r *HandlerRegistry) mustHandle(selector string, priority int, handler HandleSelection) {
+	if err := r.Handle(selector, priority, handler); err != nil {
+		panic(err)
+	}
+}
+
+// mustHandleContext registers a handler that can fail with a selector that is known to be valid
+func (r *HandlerRegistry) mustHandleContext(selector string, priority int, handler HandleSelectionContext) {
+	if err := r.HandleContext(selector, priority, handler); err != nil {
+		panic(err)
+	}
+}
+
+// Remove removes all the handlers registered with the selector
+func (r *HandlerRegistry) Remove(selector string) {
+	handlers := r.handlers[:0]
+	for _, h := range r.handlers {
+		if h.selector != selector {
+			handlers = append(handlers, h)
+		}
+	}
+	r.handlers = handlers
+}
+
+// Merge registers all the handlers of the other registry, with the same priorities.
+// For handlers with the same priority, the handlers of the other registry are used first.
+func (r *HandlerRegistry) Merge(other *HandlerRegistry) {
+	if other == nil {
+		return
+	}
+	handlers := append([]registeredHandler(nil), other.handlers...)
+	sort.SliceStable(handlers, func(i, j int) bool { return handlers[i].order < handlers[j].order })
+	for _, h := range handlers {
+		if err := r.add(h); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Find finds the handler to use for the element, and whether there is one.
+// The handler ignores any errors.
+func (r *HandlerRegistry) Find(elm *goquery.Selection) (HandleSelection, bool) {
+	if h, exists := r.find(elm); exists {
+		return h.handler, true
+	}
+	return nil, false
+}
+
+// FindContext finds the handler to use for the element, and whether there is one.
+// The handler returns any errors.
+func (r *HandlerRegistry) FindContext(elm *goquery.Selection) (HandleSelectionContext, bool) {
+	if h, exists := r.find(elm); exists {
+		return h.contextHandler, true
+	}
+	return nil, false
+}
+
+// find finds the first registered handler that matches the element
+func (r *HandlerRegistry) find(elm *goquery.Selection) (registeredHandler, bool) {
+	if len(elm.Nodes) == 0 {
+		return registeredHandler{}, false
+	}
+	for _, h := range r.handlers {
+		if h.matcher.Match(elm.Nodes[0]) {
+			return h, true
+		}
+	}
+	return registeredHandler{}, false
+}
+
+// HandleSelection converts the element with the handler to use for it.
+// Elements without a handler are left out.
+func (r *HandlerRegistry) HandleSelection(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+	if handler, exists := r.Find(elm); exists {
+		handler(i, elm, mdDoc, toMD)
+	}
+}
+
+// HandleSelectionContext converts the element with the handler to use for it, and returns
+// the error of the handler. Elements without a handler are left out.
+func (r *HandlerRegistry) HandleSelectionContext(ctx context.Context, i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMDContext) error {
+	if handler, exists := r.FindContext(elm); exists {
+		return handler(ctx, i, elm, mdDoc, toMD)
+	}
+	return nil
+}
+
+// SelectionConverterBase holds the hooks shared by the provided SelectionConverters,
+// and implements the SelectionConverter interface by calling them.
+// Unless a ContentSelectorHandler is configured, matched elements are
+// converted by the handlers in its HandlerRegistry, and the errors of the handlers
+// are returned by HandleMatchedSelectionContext. Elements with content that
+// are not matched are handled as decided by its ElementPolicy.
+type SelectionConverterBase struct {
+	Transformer            *Transformer
+	RootElementFinder      FindDocumentSelection
+	TitleFinder            FindText
+	MetadataFinder         FindFrontMatter
+	ContentSelector        FindSelection
+	ContentSelectorHandler HandleSelection
+	Handlers               *HandlerRegistry
+	ElementPolicy          ElementPolicy
+	SkipChrome             []Chrome
+}
+
+// init sets the hooks from the configuration, falling back to the defaults for any
+// that are not configured, and registers the handlers for standard HTML elements.
+func (b *SelectionConverterBase) init(conf SelectionConverterConfig, defaults SelectionConverterConfig) {
+	if conf.Transformer != nil {
+		b.Transformer = conf.Transformer
+		if b.Transformer.textCleaner == nil {
+			b.Transformer.textCleaner = NewTextCleaner(nil)
+		}
+	} else {
+		b.Transformer = NewTransformer(nil)
+	}
+
+	b.RootElementFinder = defaults.RootElementFinder
+	if conf.RootElementFinder != nil {
+		b.RootElementFinder = conf.RootElementFinder
+	}
+
+	b.TitleFinder = defaults.TitleFinder
+	if conf.TitleFinder != nil {
+		b.TitleFinder = conf.TitleFinder
+	}
+
+	b.MetadataFinder = defaults.MetadataFinder
+	if conf.MetadataFinder != nil {
+		b.MetadataFinder = conf.MetadataFinder
+	}
+
+	b.ContentSelector = defaults.ContentSelector
+	if conf.ContentSelector != nil {
+		b.ContentSelector = conf.ContentSelector
+	}
+
+	b.ContentSelectorHandler = conf.ContentSelectorHandler
+	b.SkipChrome = conf.SkipChrome
+
+	b.ElementPolicy = DropPolicy
+	if conf.ElementPolicy != nil {
+		b.ElementPolicy = conf.ElementPolicy
+	}
+	// Inline elements are handled by the Transformer, as decided by the same policy
+	if b.Transformer.policy == nil {
+		b.Transformer.policy = b.ElementPolicy
+	}
+
+	b.Handlers = NewHandlerRegistry()
+	b.registerDefaultHandlers()
+}
+
+// FindRootElement finds the root element, without the kinds of chrome to skip.
+func (b *SelectionConverterBase) FindRootElement(doc *goquery.Document) *goquery.Selection {
+	root := b.RootElementFinder(doc)
+	b.Transformer.RemoveChrome(root, b.SkipChrome)
+	return root
+}
+
+// FindTitle finds the title of the document.
+func (b *SelectionConverterBase) FindTitle(doc *goquery.Document) string {
+	return b.TitleFinder(doc)
+}
+
+// FindMetadata finds metadata about the document to render as front matter.
+func (b *SelectionConverterBase) FindMetadata(doc *goquery.Document) *markdown.FrontMatter {
+	return b.MetadataFinder(doc)
+}
+
+// FindContentElements finds the selections that that should be iterated over for content
+func (b *SelectionConverterBase) FindContentElements(s *goquery.Selection) *goquery.Selection {
+	return b.ContentSelector(s)
+}
+
+// HandleMatchedSelection handles matched selections from FindContentElements.
+// Any errors are ignored.
+func (b *SelectionConverterBase) HandleMatchedSelection(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+	if b.ContentSelectorHandler != nil {
+		b.ContentSelectorHandler(i, elm, mdDoc, toMD)
+		return
+	}
+	b.Transformer.RemoveScripts(elm)
+	b.Handlers.HandleSelection(i, elm, mdDoc, toMD)
+}
+
+// HandleMatchedSelectionContext handles matched selections from FindContentElements,
+// and returns the error of the handler, such as an error from converting child elements.
+// If the handler does not fail, then any failure of the Transformer is returned.
+func (b *SelectionConverterBase) HandleMatchedSelectionContext(ctx context.Context, i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMDContext) error {
+	var err error
+	if b.ContentSelectorHandler != nil {
+		err = withContext(b.ContentSelectorHandler)(ctx, i, elm, mdDoc, toMD)
+	} else {
+		b.Transformer.RemoveScripts(elm)
+		err = b.Handlers.HandleSelectionContext(ctx, i, elm, mdDoc, toMD)
+	}
+	if failure := b.Transformer.takeErr(); err == nil {
+		err = failure
+	}
+	return err
+}
+
+// registerDefaultHandlers registers the handlers for the elements in the DefaultSearchPattern
+// and the ContainerSearchPattern
+func (b *SelectionConverterBase) registerDefaultHandlers() {
+	b.Handlers.mustHandle("p,span", PriorityDefault, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		mdDoc.AddParagraphInlines(b.Transformer.ToInlines(elm))
+	})
+	b.Handlers.mustHandle("hr", PriorityDefault, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		mdDoc.AddHorizontalRule()
+	})
+	b.Handlers.mustHandle("h1,h2,h3,h4,h5,h6", PriorityDefault, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		inlines := b.Transformer.ToInlines(elm)
+		if len(markdown.RenderInlineText(inlines, markdown.HeadingContext)) > 0 {
+			header := markdown.NewHeaderFromInlines(mdDoc.HeaderLevel(goquery.NodeName(elm)), inlines)
+			mdDoc.AddContent(header)
+		}
+	})
+	b.Handlers.mustHandle("ul,ol", PriorityDefault, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		mdDoc.AddContent(b.Transformer.ToList(elm))
+	})
+	b.Handlers.mustHandle("table", PriorityDefault, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		mdDoc.AddContent(b.Transformer.ToTableOrHTML(elm))
+	})
+	b.Handlers.mustHandle("blockquote", PriorityDefault, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		mdDoc.AddContent(b.Transformer.ToBlockquote(elm, mdDoc.GetRenderConfig(), toMD))
+	})
+	b.Handlers.mustHandle("pre", PriorityDefault, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		mdDoc.AddContent(b.Transformer.ToCodeBlock(elm))
+	})
+	b.Handlers.mustHandleContext("div,"+ContainerSearchPattern, PriorityDefault, func(ctx context.Context, i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMDContext) error {
+		// Recurse through the container
+		content, err := toMD(ctx, elm, mdDoc.GetRenderConfig())
+		mdDoc.AddDoc(content)
+		return err
+	})
+}
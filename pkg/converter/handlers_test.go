@@ -0,0 +1,160 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func paragraphHandler(content string) HandleSelection {
+	return func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		mdDoc.AddParagraph(content)
+	}
+}
+
+func TestHandlerRegistryPriority(t *testing.T) {
+	doc := newTestDoc(`<html><body><div class="callout">Callout</div></body></html>`)
+	elm := doc.Find("div")
+
+	r := NewHandlerRegistry()
+	r.mustHandle("div.callout", PriorityCustom, paragraphHandler("callout"))
+	r.mustHandle("div", PriorityDefault, paragraphHandler("div"))
+	r.mustHandle("div", PriorityDefault, paragraphHandler("last div"))
+
+	mdDoc := markdown.NewDoc(markdown.DocConfig{})
+	r.HandleSelection(0, elm, mdDoc, nil)
+	if mdDoc.Content() != "callout" {
+		t.Errorf("Expected %s. Got %s", "callout", mdDoc.Content())
+	}
+
+	r.Remove("div.callout")
+	mdDoc = markdown.NewDoc(markdown.DocConfig{})
+	r.HandleSelection(0, elm, mdDoc, nil)
+	// The last handler registered with the same priority is used
+	if mdDoc.Content() != "last div" {
+		t.Errorf("Expected %s. Got %s", "last div", mdDoc.Content())
+	}
+}
+
+func TestHandlerRegistryInvalidSelector(t *testing.T) {
+	r := NewHandlerRegistry()
+	if err := r.Handle("div[", PriorityDefault, paragraphHandler("")); err == nil {
+		t.Errorf("Expected an error for an invalid selector")
+	}
+}
+
+func TestSelectionConverterWithCustomHandler(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<body>
+		<p>Paragraph</p>
+		<div class="callout"><p>Be careful</p></div>
+		<div><p>Other div</p></div>
+	</body>
+</html>
+`)
+	handlers := NewHandlerRegistry()
+	handlers.mustHandle("div.callout", PriorityCustom, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		mdDoc.AddBlockquote(toMD(elm, mdDoc.GetRenderConfig()))
+	})
+	s := NewHTMLSelectionConverter(SelectionConverterConfig{Handlers: handlers})
+	c := NewDocumentConverter(s, nil)
+
+	result := c.DocumentToMarkdown(doc).Content()
+	expected := "Paragraph\n\n> Be careful\n\nOther div"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+var errChart = errors.New("charts are not supported")
+
+func TestSelectionConverterWithFailingHandler(t *testing.T) {
+	content := `
+<html>
+	<body>
+		<p>Paragraph</p>
+		<div id="content"><p>Before</p><div class="chart"></div></div>
+		<p>After</p>
+	</body>
+</html>
+`
+	handlers := NewHandlerRegistry()
+	handlers.mustHandleContext("div.chart", PriorityCustom, func(ctx context.Context, i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMDContext) error {
+		return errChart
+	})
+	body := func(doc *goquery.Document) *goquery.Selection { return doc.Find("body") }
+
+	tests := map[string]SelectionConverter{
+		"html":       NewHTMLSelectionConverter(SelectionConverterConfig{Handlers: handlers}),
+		"google":     NewGoogleSelectionConverter(SelectionConverterConfig{Handlers: handlers}),
+		"confluence": NewConfluenceSelectionConverter(SelectionConverterConfig{Handlers: handlers, RootElementFinder: body}),
+	}
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := NewDocumentConverter(s, nil)
+			mdDoc, err := c.DocumentToMarkdownContext(context.Background(), newTestDoc(content))
+
+			var convErr *ConversionError
+			if !errors.As(err, &convErr) {
+				t.Fatalf("Expected a ConversionError. Got %v", err)
+			}
+			if !errors.Is(err, errChart) {
+				t.Errorf("Expected %v. Got %v", errChart, err)
+			}
+			expected := "html > body > div#content > div"
+			if convErr.Path != expected {
+				t.Errorf("Expected %s. Got %s", expected, convErr.Path)
+			}
+			expected = "Paragraph\n\nBefore"
+			if mdDoc.Content() != expected {
+				t.Errorf("Expected %s. Got %s", expected, mdDoc.Content())
+			}
+		})
+	}
+}
+
+func TestConfluencePanelWithFailingHandler(t *testing.T) {
+	content := `
+<html>
+	<body>
+		<div id="main-content">
+			<div class="confluence-information-macro confluence-information-macro-information">
+				<div class="confluence-information-macro-body"><p>Info</p><div class="chart"></div></div>
+			</div>
+		</div>
+	</body>
+</html>
+`
+	handlers := NewHandlerRegistry()
+	handlers.mustHandleContext("div.chart", PriorityCustom, func(ctx context.Context, i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMDContext) error {
+		return errChart
+	})
+	c := NewDocumentConverter(NewConfluenceSelectionConverter(SelectionConverterConfig{Handlers: handlers}), nil)
+
+	_, err := c.DocumentToMarkdownContext(context.Background(), newTestDoc(content))
+
+	if !errors.Is(err, errChart) {
+		t.Errorf("Expected %v. Got %v", errChart, err)
+	}
+}
+
+func TestSelectionConverterWithPanickingHandler(t *testing.T) {
+	handlers := NewHandlerRegistry()
+	handlers.mustHandle("div.chart", PriorityCustom, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+		panic("bad chart")
+	})
+	c := NewDocumentConverter(NewHTMLSelectionConverter(SelectionConverterConfig{Handlers: handlers}), nil)
+
+	_, err := c.DocumentToMarkdownContext(context.Background(), newTestDoc(`<html><body><p>Before</p><div class="chart"></div></body></html>`))
+
+	expected := "converting html > body > div: panic: bad chart"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %s. Got %v", expected, err)
+	}
+}
@@ -1,6 +1,11 @@
 package converter
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+	"github.com/david-mk-lawrence/htmltomd/pkg/util"
+)
 
 func TestDefaultHTMLConverter(t *testing.T) {
 	doc := newTestDoc(`
@@ -73,3 +78,246 @@ Test Paragraph 3
 		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
 	}
 }
+
+func TestHTMLConverterEscapesText(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<head>
+		<title>Learning C #</title>
+	</head>
+	<body>
+		<h2>Section *1*</h2>
+		<p>1. This is not a list</p>
+		<p># This is not a header</p>
+		<ul>
+			<li>- not nested</li>
+		</ul>
+		<table>
+			<tr>
+				<th>Operator</th>
+				<th>Meaning</th>
+			</tr>
+			<tr>
+				<td>a | b</td>
+				<td>bitwise or</td>
+			</tr>
+		</table>
+	</body>
+</html>
+`)
+
+	s := NewHTMLSelectionConverter(SelectionConverterConfig{})
+	c := NewDocumentConverter(s, nil)
+
+	result := c.DocumentToMarkdown(doc).String()
+	expected := `# Learning C \#
+
+### Section \*1\*
+
+1\. This is not a list
+
+\# This is not a header
+
+* \- not nested
+
+| Operator | Meaning |
+| --- | --- |
+| a \| b | bitwise or |`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestHTMLConverterBlockquote(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<body>
+		<blockquote>Quoted text</blockquote>
+		<blockquote>
+			<p>Quoted paragraph</p>
+			<ul>
+				<li>Quoted item</li>
+			</ul>
+			<blockquote>
+				<p>Nested quote</p>
+			</blockquote>
+		</blockquote>
+	</body>
+</html>
+`)
+
+	s := NewHTMLSelectionConverter(SelectionConverterConfig{})
+	c := NewDocumentConverter(s, nil)
+
+	result := c.DocumentToMarkdown(doc).Content()
+	expected := `> Quoted text
+
+> Quoted paragraph
+>
+> * Quoted item
+>
+> > Nested quote`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestHTMLConverterPreformattedText(t *testing.T) {
+	doc := newTestDoc("<html><body>" +
+		"<pre>def func():\n    print(\"Hello World\")\n</pre>" +
+		"<pre><code class=\"language-go\">if a &lt; b {\n\t<strong>return</strong> \"*a*\"\n}</code></pre>" +
+		"<pre>```\nfenced\n```</pre>" +
+		"</body></html>")
+
+	s := NewHTMLSelectionConverter(SelectionConverterConfig{})
+	c := NewDocumentConverter(s, nil)
+
+	result := c.DocumentToMarkdown(doc).Content()
+	expected := "```\ndef func():\n    print(\"Hello World\")\n```\n\n" +
+		"```go\nif a < b {\n\treturn \"*a*\"\n}\n```\n\n" +
+		"````\n```\nfenced\n```\n````"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestHTMLConverterFrontMatter(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<head>
+		<title>Test Doc</title>
+		<meta name="author" content="Jane Doe">
+		<meta property="article:published_time" content="2020-03-03T10:00:00Z">
+		<meta name="keywords" content="go, markdown">
+		<link rel="canonical" href="https://example.com/test-doc">
+	</head>
+	<body>
+		<p>Paragraph</p>
+	</body>
+</html>
+`)
+
+	s := NewHTMLSelectionConverter(SelectionConverterConfig{})
+	c := NewDocumentConverter(s, &DocumentConverterConf{
+		FrontMatterFormat: markdown.FrontMatterYAML,
+		TitleHeading:      util.Bool(false),
+	})
+
+	result := c.DocumentToMarkdown(doc).String()
+	expected := `---
+title: "Test Doc"
+author: "Jane Doe"
+date: 2020-03-03T10:00:00Z
+tags:
+  - "go"
+  - "markdown"
+source: "https://example.com/test-doc"
+---
+
+Paragraph`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+const semanticHTML = `
+<html>
+	<body>
+		<header><p>Site Name</p></header>
+		<nav><a href="/">Home</a></nav>
+		<main>
+			<article>
+				<header><h1>Article Title</h1></header>
+				<section>
+					<p>Section paragraph</p>
+				</section>
+				<aside><p>Aside paragraph</p></aside>
+				<footer><p>Article footer</p></footer>
+			</article>
+		</main>
+		<footer><p>Copyright</p></footer>
+	</body>
+</html>
+`
+
+func TestHTMLConverterSemanticContainers(t *testing.T) {
+	s := NewHTMLSelectionConverter(SelectionConverterConfig{})
+	c := NewDocumentConverter(s, nil)
+
+	result := c.DocumentToMarkdown(newTestDoc(semanticHTML)).Content()
+	expected := `Site Name
+
+[Home](/)
+
+## Article Title
+
+Section paragraph
+
+Aside paragraph
+
+Article footer
+
+Copyright`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestHTMLConverterSkipChrome(t *testing.T) {
+	s := NewHTMLSelectionConverter(SelectionConverterConfig{
+		SkipChrome: []Chrome{ChromeNav, ChromeHeader, ChromeFooter},
+	})
+	c := NewDocumentConverter(s, nil)
+
+	result := c.DocumentToMarkdown(newTestDoc(semanticHTML)).Content()
+	expected := `## Article Title
+
+Section paragraph
+
+Aside paragraph
+
+Article footer`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestHTMLConverterLooseText(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<body>
+		Loose <strong>bold</strong> text
+		<h1>Header</h1>
+		<div>
+			First line<br>
+			Second line
+			<br><br>
+			Next <a href="https://example.com">paragraph</a>
+			<p>Paragraph</p>
+			Trailing text<br>
+		</div>
+	</body>
+</html>
+`)
+
+	s := NewHTMLSelectionConverter(SelectionConverterConfig{})
+	c := NewDocumentConverter(s, nil)
+
+	result := c.DocumentToMarkdown(doc).Content()
+	expected := "Loose **bold** text\n\n" +
+		"## Header\n\n" +
+		"First line\\\nSecond line\n\n" +
+		"Next [paragraph](https://example.com)\n\n" +
+		"Paragraph\n\n" +
+		"Trailing text"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
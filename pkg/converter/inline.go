@@ -0,0 +1,142 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// inlinePattern matches the elements that are converted to inline markdown
+const inlinePattern = "strong,b,em,i,del,s,strike,code,tt,samp,kbd,a,img,br"
+
+var inlineTags = map[string]bool{
+	"strong": true, "b": true, "em": true, "i": true, "del": true, "s": true, "strike": true,
+	"code": true, "tt": true, "samp": true, "kbd": true, "a": true, "img": true, "br": true,
+}
+
+// ToInlines converts the content of the DOM element to inline markdown.
+// Child elements are converted recursively, so nested formatting
+// such as a link containing bold text is kept.
+func (t *Transformer) ToInlines(elm *goquery.Selection) []markdown.Inline {
+	var inlines []markdown.Inline
+	for _, node := range elm.Nodes {
+		inlines = append(inlines, t.childrenToInlines(node)...)
+	}
+	return inlines
+}
+
+// InlineText converts the content of the DOM element to markdown text
+// for the context the text will be placed in, as rendered by the Renderer of the Transformer.
+func (t *Transformer) InlineText(elm *goquery.Selection, ctx markdown.EscapeContext) string {
+	return markdown.RenderInlineText(renderInlines(t.renderer, t.ToInlines(elm)), ctx)
+}
+
+// nodesToInlines converts the DOM nodes to inline markdown
+func (t *Transformer) nodesToInlines(nodes []*html.Node) []markdown.Inline {
+	var inlines []markdown.Inline
+	for _, node := range nodes {
+		inlines = append(inlines, t.nodeToInlines(node)...)
+	}
+	return inlines
+}
+
+func (t *Transformer) childrenToInlines(node *html.Node) []markdown.Inline {
+	var inlines []markdown.Inline
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		inlines = append(inlines, t.nodeToInlines(child)...)
+	}
+	return inlines
+}
+
+// nodeToInlines converts a single DOM node to inline markdown.
+// Elements that are not inline formatting are converted to their content.
+// Elements that markdown has no formatting for are embedded as HTML instead
+// if the ElementPolicy is to pass them through, except for embedded content such as "iframe".
+func (t *Transformer) nodeToInlines(node *html.Node) []markdown.Inline {
+	switch node.Type {
+	case html.TextNode:
+		return []markdown.Inline{markdown.Text{Content: t.textCleaner.CleanInlineText(node.Data)}}
+	case html.ElementNode:
+	default:
+		return nil
+	}
+
+	switch node.Data {
+	case "strong", "b":
+		return []markdown.Inline{markdown.Strong{Children: t.childrenToInlines(node)}}
+	case "em", "i":
+		return []markdown.Inline{markdown.Emphasis{Children: t.childrenToInlines(node)}}
+	case "del", "s", "strike":
+		return []markdown.Inline{markdown.Strikethrough{Children: t.childrenToInlines(node)}}
+	case "code", "tt", "samp":
+		return []markdown.Inline{markdown.Code{Content: t.textCleaner.CleanText(nodeText(node))}}
+	case "kbd":
+		// Markdown has no syntax for keyboard input, but inline HTML is allowed
+		return []markdown.Inline{markdown.RawInline{Content: "<kbd>" + html.EscapeString(t.textCleaner.CleanText(nodeText(node))) + "</kbd>"}}
+	case "a":
+		href, exists := nodeAttr(node, "href")
+		if !exists {
+			return t.childrenToInlines(node)
+		}
+		title, _ := nodeAttr(node, "title")
+		return []markdown.Inline{markdown.Link{URL: href, Title: t.textCleaner.CleanText(title), Children: t.childrenToInlines(node)}}
+	case "img":
+		src, exists := nodeAttr(node, "src")
+		if !exists {
+			t.report(DiagnosticSkipped, node, "the image has no src")
+			return nil
+		}
+		alt, _ := nodeAttr(node, "alt")
+		return []markdown.Inline{markdown.Image{Src: src, Alt: t.textCleaner.CleanText(alt)}}
+	case "br":
+		return []markdown.Inline{markdown.LineBreak{}}
+	case "script", "style", "link", "template":
+		return nil
+	case "iframe", "video", "audio", "object", "embed", "svg", "canvas":
+		// Embedded content is not safe to pass through as HTML
+		t.report(DiagnosticSkipped, node, fmt.Sprintf("embedded content in <%s> is not converted", node.Data))
+		return nil
+	}
+
+	if unsupportedInlineTags[node.Data] {
+		if t.elementAction(node) == ElementPassthrough {
+			return t.toRawInline(node)
+		}
+		t.report(DiagnosticLossy, node, fmt.Sprintf("markdown has no formatting like <%s>, so only its text is kept", node.Data))
+	} else if style, _ := nodeAttr(node, "style"); styledTextPattern.MatchString(style) {
+		t.report(DiagnosticLossy, node, fmt.Sprintf("text styles on <%s> are ignored", node.Data))
+	}
+	return t.childrenToInlines(node)
+}
+
+// toRawInline embeds the sanitized HTML of the element in the markdown
+func (t *Transformer) toRawInline(node *html.Node) []markdown.Inline {
+	t.diagnostics.addNode(DiagnosticLossy, node, fmt.Sprintf("<%s> is not converted, so it is embedded as HTML", node.Data))
+	return []markdown.Inline{markdown.RawInline{Content: t.ToRawHTML(goquery.NewDocumentFromNode(node).Selection).Content}}
+}
+
+// nodeAttr gets the value of the attribute on the node, and whether the node has the attribute.
+func nodeAttr(node *html.Node, key string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// nodeText gets the text of the node and all its children.
+func nodeText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var text strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		text.WriteString(nodeText(child))
+	}
+	return text.String()
+}
@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+)
+
+func TestInlineTextNestedFormatting(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`
+<html>
+	<body>
+		<p>
+			A <a href="mock://example.com"><strong>bold link</strong></a>,
+			<strong>bold <em>and italic</em></strong>,
+			<em>italic <a href="mock://example.com">link</a></em>,
+			<b>b</b> and <i>i</i>, <del>removed</del>,
+			<code>code with <strong>tags</strong></code>
+			and <a href="mock://example.com"><img src="mock://example.com/img.png" alt="image"></a>.
+		</p>
+	</body>
+</html>
+`)
+
+	result := tr.InlineText(doc.Find("p"), markdown.ParagraphContext)
+	expected := "A [**bold link**](mock://example.com), **bold _and italic_**, _italic [link](mock://example.com)_, " +
+		"**b** and _i_, ~~removed~~, `code with tags` and [![image](mock://example.com/img.png)](mock://example.com)."
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestInlineTextWhitespace(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`<html><body><p>  This is<strong> bold </strong>text<em> </em>with   extra
+	whitespace<br></p></body></html>`)
+
+	result := tr.InlineText(doc.Find("p"), markdown.ParagraphContext)
+	expected := "This is **bold** text with extra whitespace"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestInlineTextLineBreaks(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`<html><body><p>line 1<br>line 2</p></body></html>`)
+
+	result := tr.InlineText(doc.Find("p"), markdown.ParagraphContext)
+	expected := "line 1\\\nline 2"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestInlineTextHugoImage(t *testing.T) {
+	tr := NewTransformer(&TransformerConf{Renderer: HugoRenderer{}})
+	doc := newTestDoc(`<html><body><p><img src="image.png" alt="Image"></p></body></html>`)
+
+	result := tr.InlineText(doc.Find("p"), markdown.ParagraphContext)
+	expected := `{{< figure src="./image.png" alt="Image" >}}`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
@@ -0,0 +1,205 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+)
+
+var (
+	// mdxAdmonitions are the Docusaurus admonitions of each kind of callout
+	mdxAdmonitions = map[string]string{
+		AdmonitionNote:    "note",
+		AdmonitionInfo:    "info",
+		AdmonitionWarning: "warning",
+		AdmonitionTip:     "tip",
+		AdmonitionError:   "danger",
+	}
+
+	// mdxVoidTags are the HTML elements without a closing tag, which must be self-closing in JSX
+	mdxVoidTags = map[string]bool{
+		"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+		"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+	}
+
+	// mdxAttrs are the HTML attributes that have another name in JSX
+	mdxAttrs = map[string]string{"colspan": "colSpan", "rowspan": "rowSpan", "class": "className"}
+
+	mdxTagPattern   = regexp.MustCompile(`^<(/?)([A-Za-z][A-Za-z0-9-]*)((?:\s+[^<>]*?)?)(/?)>`)
+	mdxAttrPattern  = regexp.MustCompile(`(\s)(colspan|rowspan|class)=`)
+	mdxFencePattern = regexp.MustCompile("^[\\s>]*(`{3,}|~{3,})")
+	mdxSlugPattern  = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// MDXRenderer renders MDX for a Docusaurus website. Admonitions are rendered as ":::note" blocks,
+// and characters that JSX gives a meaning to, such as "{" and "<", are escaped outside of code.
+// Each document has YAML front matter with its id and title, which Docusaurus uses for the page.
+type MDXRenderer struct {
+	MarkdownRenderer
+}
+
+// Name is "mdx"
+func (MDXRenderer) Name() string { return OutputMDX }
+
+// FrontMatterFormat is YAML, the only front matter Docusaurus reads
+func (MDXRenderer) FrontMatterFormat() markdown.FrontMatterFormat { return markdown.FrontMatterYAML }
+
+// Admonition wraps the content in a Docusaurus admonition. Errors are "danger" admonitions.
+func (MDXRenderer) Admonition(kind string, content *markdown.Doc) fmt.Stringer {
+	admonition, exists := mdxAdmonitions[kind]
+	if !exists {
+		admonition = mdxAdmonitions[AdmonitionNote]
+	}
+
+	wrapper := markdown.NewDoc(markdown.DocConfig{})
+	wrapper.AddParagraph(":::" + admonition)
+	wrapper.AddDoc(content)
+	wrapper.AddParagraph(":::")
+	return wrapper
+}
+
+// RenderDocument renders the document as MDX, with its components rendered by the MDXRenderer.
+// The id of the document is the title in lowercase with dashes between words, unless the front
+// matter already has an id.
+func (r MDXRenderer) RenderDocument(doc *markdown.Doc) (string, error) {
+	doc = RenderComponents(r, doc)
+	if frontMatter := doc.FrontMatter(); frontMatter != nil {
+		frontMatter.Format = markdown.FrontMatterYAML
+		if _, exists := frontMatter.Get("id"); !exists {
+			if title, _ := frontMatter.Get("title"); title != nil {
+				if id := mdxSlug(fmt.Sprint(title)); id != "" {
+					frontMatter.Set("id", id)
+				}
+			}
+		}
+	}
+	rendered := EscapeMDX(doc.Body())
+	if frontMatter := doc.FrontMatter().String(); frontMatter != "" {
+		rendered = frontMatter + "\n\n" + rendered
+	}
+	return rendered + "\n", nil
+}
+
+// EscapeMDX escapes the markdown so that it can be compiled as MDX. Outside of code,
+// "{" and "}" are escaped, "<" is escaped unless it starts an HTML tag, HTML comments
+// become MDX comments, and HTML tags are made valid JSX.
+func EscapeMDX(content string) string {
+	lines := strings.Split(content, "\n")
+	escaped := make([]string, 0, len(lines))
+
+	fence := ""
+	inComment := false
+	for _, line := range lines {
+		if fence != "" {
+			if closesFence(line, fence) {
+				fence = ""
+			}
+			escaped = append(escaped, line)
+			continue
+		}
+		if match := mdxFencePattern.FindStringSubmatch(line); match != nil && !inComment {
+			fence = match[1]
+			escaped = append(escaped, line)
+			continue
+		}
+		line, inComment = escapeMDXLine(line, inComment)
+		escaped = append(escaped, line)
+	}
+
+	return strings.Join(escaped, "\n")
+}
+
+// closesFence checks whether the line is the closing fence of a code block opened with the fence
+func closesFence(line string, fence string) bool {
+	trimmed := strings.TrimSpace(strings.TrimLeft(line, " \t>"))
+	return len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// escapeMDXLine escapes a line of markdown outside of a code block.
+// inComment is whether the line starts within an HTML comment, and the result
+// is whether the next line does.
+func escapeMDXLine(line string, inComment bool) (string, bool) {
+	var escaped strings.Builder
+	for idx := 0; idx < len(line); {
+		if inComment {
+			end := strings.Index(line[idx:], "-->")
+			if end < 0 {
+				escaped.WriteString(strings.ReplaceAll(line[idx:], "*/", "* /"))
+				return escaped.String(), true
+			}
+			escaped.WriteString(strings.ReplaceAll(line[idx:idx+end], "*/", "* /"))
+			escaped.WriteString("*/}")
+			idx += end + len("-->")
+			inComment = false
+			continue
+		}
+
+		switch char := line[idx]; {
+		case char == '\\' && idx+1 < len(line):
+			escaped.WriteString(line[idx : idx+2])
+			idx += 2
+		case char == '`':
+			span := codeSpan(line[idx:])
+			escaped.WriteString(span)
+			idx += len(span)
+		case char == '{' || char == '}':
+			escaped.WriteString("\\" + string(char))
+			idx++
+		case strings.HasPrefix(line[idx:], "<!--"):
+			escaped.WriteString("{/*")
+			idx += len("<!--")
+			inComment = true
+		case char == '<':
+			if tag := mdxTagPattern.FindStringSubmatch(line[idx:]); tag != nil {
+				escaped.WriteString(jsxTag(tag))
+				idx += len(tag[0])
+			} else {
+				escaped.WriteString("\\<")
+				idx++
+			}
+		default:
+			escaped.WriteByte(char)
+			idx++
+		}
+	}
+	return escaped.String(), inComment
+}
+
+// codeSpan gets the code span at the start of the text, which is left as is.
+// If the backticks are not closed, then only the backticks are a span.
+func codeSpan(text string) string {
+	ticks := len(text) - len(strings.TrimLeft(text, "`"))
+	for idx := ticks; idx < len(text); {
+		end := strings.Index(text[idx:], "`")
+		if end < 0 {
+			break
+		}
+		idx += end
+		run := len(text[idx:]) - len(strings.TrimLeft(text[idx:], "`"))
+		if run == ticks {
+			return text[:idx+run]
+		}
+		idx += run
+	}
+	return text[:ticks]
+}
+
+// jsxTag renames the attributes of the HTML tag that have another name in JSX,
+// and makes tags of void elements, such as "<br>", self-closing
+func jsxTag(tag []string) string {
+	closing, name, attrs, selfClosing := tag[1], tag[2], tag[3], tag[4]
+	attrs = mdxAttrPattern.ReplaceAllStringFunc(attrs, func(attr string) string {
+		return attr[:1] + mdxAttrs[strings.TrimSuffix(attr[1:], "=")] + "="
+	})
+	if closing == "" && selfClosing == "" && mdxVoidTags[strings.ToLower(name)] {
+		return "<" + name + strings.TrimRight(attrs, " ") + " />"
+	}
+	return "<" + closing + name + attrs + selfClosing + ">"
+}
+
+// mdxSlug converts the title to an id, such as "Getting Started" to "getting-started"
+func mdxSlug(title string) string {
+	return strings.Trim(mdxSlugPattern.ReplaceAllString(strings.ToLower(title), "-"), "-")
+}
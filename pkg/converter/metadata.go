@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// dateLayouts are the layouts that dates in metadata are parsed with.
+// Dates that cannot be parsed are kept as text.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"Jan 02, 2006",
+	"Jan 2, 2006",
+}
+
+// findHTMLMetadata finds metadata that is commonly found in the "meta"
+// and "link" elements of the head of an HTML document.
+func findHTMLMetadata(doc *goquery.Document, t *Transformer) *markdown.FrontMatter {
+	frontMatter := markdown.NewFrontMatter(markdown.FrontMatterNone)
+
+	if author := findMeta(doc, t, `meta[name="author"]`, `meta[property="article:author"]`); author != "" {
+		frontMatter.Set("author", author)
+	}
+	if date := findMeta(doc, t, `meta[name="date"]`, `meta[property="article:published_time"]`, `meta[name="dcterms.created"]`); date != "" {
+		frontMatter.Set("date", parseDate(date))
+	}
+	if lastmod := findMeta(doc, t, `meta[property="article:modified_time"]`, `meta[name="last-modified"]`, `meta[name="dcterms.modified"]`); lastmod != "" {
+		frontMatter.Set("lastmod", parseDate(lastmod))
+	}
+	if description := findMeta(doc, t, `meta[name="description"]`, `meta[property="og:description"]`); description != "" {
+		frontMatter.Set("description", description)
+	}
+
+	var tags []string
+	doc.Find(`meta[property="article:tag"]`).Each(func(i int, s *goquery.Selection) {
+		if tag := t.CleanText(s.AttrOr("content", "")); tag != "" {
+			tags = append(tags, tag)
+		}
+	})
+	if len(tags) == 0 {
+		for _, keyword := range strings.Split(findMeta(doc, t, `meta[name="keywords"]`), ",") {
+			if tag := t.CleanText(keyword); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	if len(tags) > 0 {
+		frontMatter.Set("tags", tags)
+	}
+
+	if source := doc.Find(`link[rel="canonical"]`).First().AttrOr("href", ""); source != "" {
+		frontMatter.Set("source", source)
+	} else if source := findMeta(doc, t, `meta[property="og:url"]`); source != "" {
+		frontMatter.Set("source", source)
+	}
+
+	return frontMatter
+}
+
+// findMeta finds the content of the first "meta" element matching any of the selectors.
+func findMeta(doc *goquery.Document, t *Transformer, selectors ...string) string {
+	for _, selector := range selectors {
+		if content := t.CleanText(doc.Find(selector).First().AttrOr("content", "")); content != "" {
+			return content
+		}
+	}
+	return ""
+}
+
+// parseDate parses the date if it is in a known layout.
+// Otherwise, the date is returned as text.
+func parseDate(date string) interface{} {
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, date); err == nil {
+			return parsed
+		}
+	}
+	return date
+}
@@ -0,0 +1,97 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+)
+
+// mkdocsAdmonitions are the Material for MkDocs admonitions of each kind of callout
+var mkdocsAdmonitions = map[string]string{
+	AdmonitionNote:    "note",
+	AdmonitionInfo:    "info",
+	AdmonitionWarning: "warning",
+	AdmonitionTip:     "tip",
+	AdmonitionError:   "danger",
+}
+
+// mkdocsIndent is the indent of the content of an admonition
+const mkdocsIndent = "    "
+
+// MkDocsRenderer renders markdown for a MkDocs website with the Material theme. Admonitions are
+// rendered as "!!!" blocks with their titles, or "???" blocks if they are collapsible, with their
+// content indented. Code blocks keep their title as a title="..." attribute.
+type MkDocsRenderer struct {
+	MarkdownRenderer
+}
+
+// Name is "mkdocs"
+func (MkDocsRenderer) Name() string { return OutputMkDocs }
+
+// Admonition renders the admonition as "!!! note" followed by the indented content.
+// Errors are "danger" admonitions.
+func (MkDocsRenderer) Admonition(kind string, content *markdown.Doc) fmt.Stringer {
+	return mkdocsAdmonition(markdown.Admonition{Kind: kind, Content: content})
+}
+
+// RenderDocument renders the document as markdown, with its components rendered by the
+// MkDocsRenderer. Admonitions and code blocks are rendered along with their titles,
+// which the Renderer methods are not given.
+func (r MkDocsRenderer) RenderDocument(doc *markdown.Doc) (string, error) {
+	rendered := doc.Clone()
+	markdown.Rewrite(rendered, func(node markdown.Node) markdown.Node {
+		switch n := node.(type) {
+		case markdown.Admonition:
+			return mkdocsAdmonition(n)
+		case markdown.CodeBlock:
+			return mkdocsCodeBlock(n)
+		}
+		return renderComponent(r, node)
+	})
+	return rendered.String() + "\n", nil
+}
+
+// mkdocsAdmonition renders the admonition as `!!! note "Title"`, or `??? note "Title"` if it
+// is collapsible, followed by the content indented below it
+func mkdocsAdmonition(admonition markdown.Admonition) markdown.RawBlock {
+	kind, exists := mkdocsAdmonitions[admonition.Kind]
+	if !exists {
+		kind = mkdocsAdmonitions[AdmonitionNote]
+	}
+
+	marker := "!!!"
+	if admonition.Collapsible {
+		marker = "???"
+	}
+	header := marker + " " + kind
+	if admonition.Title != "" {
+		header += " " + mkdocsQuote(admonition.Title)
+	}
+	if admonition.Content == nil {
+		return markdown.RawBlock{Content: header}
+	}
+
+	lines := strings.Split(admonition.Content.String(), "\n")
+	for idx, line := range lines {
+		if line != "" {
+			lines[idx] = mkdocsIndent + line
+		}
+	}
+	return markdown.RawBlock{Content: header + "\n\n" + strings.Join(lines, "\n")}
+}
+
+// mkdocsCodeBlock renders the code block with its title as a title="..." attribute after the language
+func mkdocsCodeBlock(code markdown.CodeBlock) markdown.Block {
+	if code.Title == "" {
+		return code
+	}
+	fence, rest, _ := strings.Cut(code.String(), "\n")
+	return markdown.RawBlock{Content: fence + " title=" + mkdocsQuote(code.Title) + "\n" + rest}
+}
+
+// mkdocsQuote quotes the title of an admonition or code block. The title cannot contain
+// a quote, since it is not escaped by MkDocs, so any quote is replaced by a single quote.
+func mkdocsQuote(title string) string {
+	return `"` + strings.ReplaceAll(title, `"`, "'") + `"`
+}
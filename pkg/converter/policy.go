@@ -0,0 +1,201 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// ErrUnsupportedElement is the error when an element cannot be converted to markdown
+// and the ElementPolicy is to fail
+var ErrUnsupportedElement = errors.New("element cannot be converted to markdown")
+
+// ElementAction is what is done with an element that has content, but is not converted
+type ElementAction int
+
+const (
+	// ElementDrop leaves the element out of the markdown
+	ElementDrop ElementAction = iota
+	// ElementText adds the text of the element as a paragraph
+	ElementText
+	// ElementPassthrough embeds the sanitized HTML of the element in the markdown
+	ElementPassthrough
+	// ElementFail fails the conversion with ErrUnsupportedElement
+	ElementFail
+)
+
+// elementActionNames are the names of the ElementActions
+var elementActionNames = map[ElementAction]string{
+	ElementDrop:        "drop",
+	ElementText:        "text",
+	ElementPassthrough: "passthrough",
+	ElementFail:        "fail",
+}
+
+// String gets the name of the action
+func (a ElementAction) String() string {
+	return elementActionNames[a]
+}
+
+// ParseElementAction gets the ElementAction by name. Can be "drop", "text", "passthrough", or "fail".
+func ParseElementAction(name string) (ElementAction, error) {
+	for action, actionName := range elementActionNames {
+		if strings.EqualFold(name, actionName) {
+			return action, nil
+		}
+	}
+	return ElementDrop, fmt.Errorf("unknown element action %q. Can be 'drop', 'text', 'passthrough', or 'fail'", name)
+}
+
+// ElementPolicy decides what to do with an element that has content, but is not matched
+// by the content selector, such as a "dl" or "iframe". It is also used for inline elements
+// that markdown has no formatting for, such as "u", which are converted to their text
+// unless the action is to pass them through or fail. If the action is to
+// fail for any content that is left out or loses formatting, then the conversion fails.
+type ElementPolicy func(*goquery.Selection) ElementAction
+
+// DropPolicy leaves all unconverted elements out of the markdown. This is the default policy.
+func DropPolicy(*goquery.Selection) ElementAction { return ElementDrop }
+
+// TextPolicy keeps the text of all unconverted elements
+func TextPolicy(*goquery.Selection) ElementAction { return ElementText }
+
+// PassthroughPolicy embeds the HTML of all unconverted elements in the markdown
+func PassthroughPolicy(*goquery.Selection) ElementAction { return ElementPassthrough }
+
+// StrictPolicy fails the conversion at the first content that is left out or loses formatting
+func StrictPolicy(*goquery.Selection) ElementAction { return ElementFail }
+
+// policyFails checks whether the policy is to fail for the node
+func policyFails(policy ElementPolicy, node *html.Node) bool {
+	return policy != nil && policy(goquery.NewDocumentFromNode(node).Selection) == ElementFail
+}
+
+// unsupportedError is the error for the node when the ElementPolicy is to fail for it
+func unsupportedError(node *html.Node, message string) error {
+	return &ConversionError{Path: nodePath(node), Err: fmt.Errorf("%w: %s", ErrUnsupportedElement, message)}
+}
+
+// elementAction decides what to do with the inline element, as decided by the ElementPolicy
+func (t *Transformer) elementAction(node *html.Node) ElementAction {
+	if t.policy == nil {
+		return ElementDrop
+	}
+	return t.policy(goquery.NewDocumentFromNode(node).Selection)
+}
+
+// report records a diagnostic for the node. If the ElementPolicy is to fail for the node,
+// then the failure is also recorded, and is returned by Err.
+func (t *Transformer) report(kind DiagnosticKind, node *html.Node, message string) {
+	t.diagnostics.addNode(kind, node, message)
+	if policyFails(t.policy, node) {
+		t.failureMu.Lock()
+		defer t.failureMu.Unlock()
+		if t.failure == nil {
+			t.failure = unsupportedError(node, message)
+		}
+	}
+}
+
+// Err gets the first failure of the Transformer, which is a *ConversionError for content that is
+// left out or loses formatting when the ElementPolicy is to fail for it. It is nil if nothing failed.
+// The SelectionConverters return the failure as the error of the element being converted.
+func (t *Transformer) Err() error {
+	t.failureMu.Lock()
+	defer t.failureMu.Unlock()
+	return t.failure
+}
+
+// takeErr gets the first failure and clears it, so that it fails only the element being converted
+func (t *Transformer) takeErr() error {
+	t.failureMu.Lock()
+	defer t.failureMu.Unlock()
+	err := t.failure
+	t.failure = nil
+	return err
+}
+
+// ElementRule converts the elements that match the CSS selector with the action, instead of
+// the handler they would otherwise be converted with. The rule is registered as a handler
+// with the priority, or PriorityCustom if it is 0. A rule cannot fail, so ElementFail is
+// not a valid action.
+type ElementRule struct {
+	Selector string
+	Action   ElementAction
+	Priority int
+}
+
+// registerElementRules registers a handler in the registry for each of the rules
+func (t *Transformer) registerElementRules(registry *HandlerRegistry, rules []ElementRule) error {
+	for _, rule := range rules {
+		if rule.Action == ElementFail {
+			return fmt.Errorf("invalid action for %q: %s", rule.Selector, rule.Action)
+		}
+		priority := rule.Priority
+		if priority == 0 {
+			priority = PriorityCustom
+		}
+
+		action := rule.Action
+		err := registry.Handle(rule.Selector, priority, func(i int, elm *goquery.Selection, mdDoc *markdown.Doc, toMD SelectionToMD) {
+			t.applyElementAction(embeddableAction(action, elm), elm, mdDoc)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnknownElementConverter is an optional interface for a SelectionConverter that handles
+// the elements that have content, but are not matched by FindContentElements.
+// Otherwise, the elements are left out of the markdown.
+type UnknownElementConverter interface {
+	HandleUnknownSelection(*goquery.Selection, *markdown.Doc) error
+}
+
+// HandleUnknownSelection handles an element that is not matched by FindContentElements,
+// as decided by the ElementPolicy.
+func (b *SelectionConverterBase) HandleUnknownSelection(elm *goquery.Selection, mdDoc *markdown.Doc) error {
+	action := embeddableAction(b.ElementPolicy(elm), elm)
+	if action == ElementFail {
+		return ErrUnsupportedElement
+	}
+
+	name := goquery.NodeName(elm)
+	switch action {
+	case ElementText:
+		b.Transformer.diagnostics.Add(DiagnosticLossy, elm, fmt.Sprintf("<%s> is not converted, so only its text is kept", name))
+	case ElementPassthrough:
+		b.Transformer.diagnostics.Add(DiagnosticLossy, elm, fmt.Sprintf("<%s> is not converted, so it is embedded as HTML", name))
+	default:
+		b.Transformer.diagnostics.Add(DiagnosticSkipped, elm, fmt.Sprintf("<%s> is not converted", name))
+	}
+	b.Transformer.applyElementAction(action, elm, mdDoc)
+	return b.Transformer.takeErr()
+}
+
+// embeddableAction is the action for the element, except that an element that is not safe
+// to embed as HTML, such as an "iframe" or "form", is dropped instead of passed through
+func embeddableAction(action ElementAction, elm *goquery.Selection) ElementAction {
+	if action == ElementPassthrough && !rawHTMLTags[goquery.NodeName(elm)] {
+		return ElementDrop
+	}
+	return action
+}
+
+// applyElementAction adds the text or HTML of the element to the markdown, as decided by the action
+func (t *Transformer) applyElementAction(action ElementAction, elm *goquery.Selection, mdDoc *markdown.Doc) {
+	switch action {
+	case ElementText:
+		t.RemoveScripts(elm)
+		mdDoc.AddParagraphInlines(t.ToInlines(elm))
+	case ElementPassthrough:
+		mdDoc.AddContent(t.ToRawHTML(elm))
+	}
+}
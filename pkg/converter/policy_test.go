@@ -0,0 +1,195 @@
+package converter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const policyTestHTML = `
+<html>
+	<head>
+		<title>Page Title</title>
+	</head>
+	<body>
+		<p>Before</p>
+		<dl><dd>Some <em>definition</em> text</dd><script>ignored()</script></dl>
+		<p>After</p>
+	</body>
+</html>
+`
+
+func TestElementPolicy(t *testing.T) {
+	tests := []struct {
+		policy   ElementPolicy
+		expected string
+	}{
+		{DropPolicy, "# Page Title\n\nBefore\n\nAfter\n"},
+		{TextPolicy, "# Page Title\n\nBefore\n\nSome _definition_ text\n\nAfter\n"},
+		{PassthroughPolicy, "# Page Title\n\nBefore\n\n<dl><dd>Some <em>definition</em> text</dd></dl>\n\nAfter\n"},
+	}
+
+	for _, test := range tests {
+		result, err := ConvertString(policyTestHTML, WithElementPolicy(test.policy))
+		if err != nil {
+			t.Fatalf("Expected no error. Got %s", err)
+		}
+		if result != test.expected {
+			t.Errorf("Expected\n%s\nGot\n%s", test.expected, result)
+		}
+	}
+}
+
+func TestStrictElementPolicy(t *testing.T) {
+	_, err := ConvertString(policyTestHTML, WithElementPolicy(StrictPolicy))
+	if !errors.Is(err, ErrUnsupportedElement) {
+		t.Fatalf("Expected %s. Got %v", ErrUnsupportedElement, err)
+	}
+
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("Expected a ConversionError. Got %T", err)
+	}
+	expected := "html > body > dl"
+	if convErr.Path != expected {
+		t.Errorf("Expected %s. Got %s", expected, convErr.Path)
+	}
+}
+
+func TestPassthroughPolicyUnsafeElements(t *testing.T) {
+	content := `<html><body><p>Before</p><form action="/"><label>Search</label><input name="q"></form><iframe src="https://example.com"></iframe><p>After</p></body></html>`
+	diagnostics := NewDiagnostics()
+
+	result, err := ConvertString(content, WithElementPolicy(PassthroughPolicy), WithDiagnostics(diagnostics), WithTitleHeading(false))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	expected := "Before\n\nAfter\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+
+	for _, diagnostic := range diagnostics.All() {
+		if diagnostic.Kind != DiagnosticSkipped {
+			t.Errorf("Expected the unsafe elements to be skipped. Got %s", diagnostic)
+		}
+	}
+	if diagnostics.Len() != 2 {
+		t.Errorf("Expected 2 diagnostics. Got %v", diagnostics.All())
+	}
+}
+
+func TestElementPolicyPerElement(t *testing.T) {
+	content := `<html><body><dl><dd>Definition</dd></dl><form><label>Form</label></form></body></html>`
+	policy := func(elm *goquery.Selection) ElementAction {
+		if goquery.NodeName(elm) == "form" {
+			return ElementText
+		}
+		return ElementDrop
+	}
+
+	result, err := ConvertString(content, WithElementPolicy(policy), WithTitleHeading(false))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	expected := "Form\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestStrictElementPolicyInline(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		path    string
+	}{
+		"embedded content": {
+			content: `<p>Intro <iframe></iframe> text</p>`,
+			path:    "html > body > p > iframe",
+		},
+		"unsupported formatting": {
+			content: `<p>Intro <u>under</u></p>`,
+			path:    "html > body > p > u",
+		},
+		"styled text": {
+			content: `<div><p>Intro <span style="font-weight: bold">bold</span></p></div>`,
+			path:    "html > body > div > p > span",
+		},
+		"spanned cell": {
+			content: `<table><tr><th colspan="2">Header</th></tr><tr><td>1</td><td>2</td></tr></table>`,
+			path:    "html > body > table > tbody > tr:nth-of-type(1) > th",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ConvertString("<html><body>"+test.content+"</body></html>", WithElementPolicy(StrictPolicy))
+			if !errors.Is(err, ErrUnsupportedElement) {
+				t.Fatalf("Expected %s. Got %v", ErrUnsupportedElement, err)
+			}
+			var convErr *ConversionError
+			if !errors.As(err, &convErr) {
+				t.Fatalf("Expected a ConversionError. Got %T", err)
+			}
+			if convErr.Path != test.path {
+				t.Errorf("Expected %s. Got %s", test.path, convErr.Path)
+			}
+		})
+	}
+}
+
+func TestStrictTransformer(t *testing.T) {
+	tr := NewTransformer(&TransformerConf{ElementPolicy: StrictPolicy})
+	doc := newTestDoc(`<html><body><p>Intro <u>under</u></p><table><tr><td colspan="2">Cell</td></tr></table></body></html>`)
+
+	inlines := markdown.RenderInlineText(tr.ToInlines(doc.Find("p")), markdown.ParagraphContext)
+	table := tr.ToTable(doc.Find("table")).String()
+	if inlines != "Intro under" || table != "| Cell |  |\n| --- | --- |" {
+		t.Errorf("Expected the content to be converted. Got %s and %s", inlines, table)
+	}
+
+	var convErr *ConversionError
+	if err := tr.Err(); !errors.Is(err, ErrUnsupportedElement) || !errors.As(err, &convErr) {
+		t.Fatalf("Expected a ConversionError for %s. Got %v", ErrUnsupportedElement, err)
+	}
+	expected := "html > body > p > u"
+	if convErr.Path != expected {
+		t.Errorf("Expected the first failure at %s. Got %s", expected, convErr.Path)
+	}
+}
+
+func TestElementPolicyInline(t *testing.T) {
+	content := `<html><body><p>Intro <iframe src="https://example.com/video"></iframe> text <u>under</u></p></body></html>`
+	tests := map[string]struct {
+		policy   ElementPolicy
+		expected string
+	}{
+		"drop":        {DropPolicy, "Intro text under\n"},
+		"text":        {TextPolicy, "Intro text under\n"},
+		"passthrough": {PassthroughPolicy, "Intro text <u>under</u>\n"},
+		"per element": {
+			func(elm *goquery.Selection) ElementAction {
+				if goquery.NodeName(elm) == "u" {
+					return ElementPassthrough
+				}
+				return ElementDrop
+			},
+			"Intro text <u>under</u>\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := ConvertString(content, WithElementPolicy(test.policy), WithTitleHeading(false))
+			if err != nil {
+				t.Fatalf("Expected no error. Got %s", err)
+			}
+			if result != test.expected {
+				t.Errorf("Expected\n%s\nGot\n%s", test.expected, result)
+			}
+		})
+	}
+}
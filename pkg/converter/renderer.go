@@ -0,0 +1,258 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+	"github.com/david-mk-lawrence/htmltomd/pkg/util"
+)
+
+const (
+	// AdmonitionNote is a note callout, such as a Confluence note panel
+	AdmonitionNote = "note"
+	// AdmonitionInfo is an informational callout
+	AdmonitionInfo = "info"
+	// AdmonitionWarning is a warning callout
+	AdmonitionWarning = "warning"
+	// AdmonitionTip is a tip callout
+	AdmonitionTip = "tip"
+	// AdmonitionError is an error callout
+	AdmonitionError = "error"
+)
+
+// Renderer renders the markdown components of a flavor of markdown, such as standard markdown
+// or markdown for a Hugo website. The Transformer converts elements to a document of markdown
+// components, which Render passes to the Renderer, which may return them as they are, or render
+// them differently, such as with a shortcode.
+type Renderer interface {
+	// Name is the output format of the flavor, such as "md" or "hugo"
+	Name() string
+	// Image renders an image
+	Image(img markdown.Image) markdown.Inline
+	// Link renders a link
+	Link(link markdown.Link) markdown.Inline
+	// Heading renders a header
+	Heading(header markdown.Header) fmt.Stringer
+	// CodeBlock renders a block of code
+	CodeBlock(code markdown.CodeBlock) fmt.Stringer
+	// Table renders a table
+	Table(table markdown.Table) fmt.Stringer
+	// Admonition renders a callout of the kind, such as "note" or "warning", around the content
+	Admonition(kind string, content *markdown.Doc) fmt.Stringer
+}
+
+// DocumentRenderer is an optional interface for a Renderer that renders the whole document,
+// rather than markdown, such as the JSON of the document tree. The components of the document
+// have not been rendered, and each block of the document has its position in the HTML source.
+type DocumentRenderer interface {
+	RenderDocument(doc *markdown.Doc) (string, error)
+}
+
+// FrontMatterRenderer is an optional interface for a Renderer whose flavor reads the metadata
+// of each page from front matter, such as Docusaurus. Documents rendered by it have front matter
+// in the format it returns, unless the front matter is turned off with FrontMatterNone.
+type FrontMatterRenderer interface {
+	FrontMatterFormat() markdown.FrontMatterFormat
+}
+
+// MarkdownRenderer renders standard markdown. Admonitions are rendered as just their content,
+// since standard markdown has no callouts.
+// It can be embedded in other Renderers to only change how some components are rendered.
+type MarkdownRenderer struct{}
+
+// Name is "md"
+func (MarkdownRenderer) Name() string { return OutputMarkdown }
+
+// Image renders the image as ![alt](src)
+func (MarkdownRenderer) Image(img markdown.Image) markdown.Inline { return img }
+
+// Link renders the link as [text](url)
+func (MarkdownRenderer) Link(link markdown.Link) markdown.Inline { return link }
+
+// Heading renders the header with #'s
+func (MarkdownRenderer) Heading(header markdown.Header) fmt.Stringer { return header }
+
+// CodeBlock renders the code in a ``` fence
+func (MarkdownRenderer) CodeBlock(code markdown.CodeBlock) fmt.Stringer { return code }
+
+// Table renders the table as a markdown table
+func (MarkdownRenderer) Table(table markdown.Table) fmt.Stringer { return table }
+
+// Admonition renders just the content
+func (MarkdownRenderer) Admonition(kind string, content *markdown.Doc) fmt.Stringer { return content }
+
+// HugoRenderer renders markdown for a Hugo website. Images are rendered as figure shortcodes,
+// and admonitions as notice shortcodes.
+type HugoRenderer struct {
+	MarkdownRenderer
+}
+
+// Name is "hugo"
+func (HugoRenderer) Name() string { return OutputHugo }
+
+// Image renders the image as a Hugo figure shortcode
+func (HugoRenderer) Image(img markdown.Image) markdown.Inline {
+	return markdown.RawInline{Content: fmt.Sprintf("{{< figure src=\"./%s\" alt=\"%s\" >}}", img.Src, img.Alt)}
+}
+
+// Admonition wraps the content in a Hugo notice shortcode
+func (HugoRenderer) Admonition(kind string, content *markdown.Doc) fmt.Stringer {
+	// Wrap the content with another document with a single newline as separator
+	// This will place the shortcode wrappers directly before and after the content
+	wrapper := markdown.NewDoc(markdown.DocConfig{Separator: util.String("\n")})
+	wrapper.AddParagraph(fmt.Sprintf("{{%% notice %s %%}}", kind))
+	wrapper.AddDoc(content)
+	wrapper.AddParagraph("{{% /notice %}}")
+	return wrapper
+}
+
+// JSONRenderer renders the tree of the document as JSON, with the type of each block and inline,
+// and the position in the HTML source of each block. Text within the tree is standard markdown.
+// The JSON can be decoded back into a markdown.Doc.
+type JSONRenderer struct {
+	MarkdownRenderer
+}
+
+// Name is "json"
+func (JSONRenderer) Name() string { return OutputJSON }
+
+// RenderDocument renders the document as indented JSON
+func (JSONRenderer) RenderDocument(doc *markdown.Doc) (string, error) {
+	var content strings.Builder
+	encoder := json.NewEncoder(&content)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return "", err
+	}
+	return content.String(), nil
+}
+
+// Render renders the document with the Renderer, which is a MarkdownRenderer if it is nil.
+// A DocumentRenderer renders the whole document. Otherwise, the document is rendered as markdown
+// with its components rendered by the Renderer, and the document itself is not changed.
+// The rendered document ends with a newline.
+func Render(r Renderer, doc *markdown.Doc) (string, error) {
+	if r == nil {
+		r = MarkdownRenderer{}
+	}
+	if docRenderer, rendersDocument := r.(DocumentRenderer); rendersDocument {
+		return docRenderer.RenderDocument(doc)
+	}
+	return RenderComponents(r, doc).String() + "\n", nil
+}
+
+// RenderComponents copies the document with its components rendered by the Renderer,
+// such as its images rendered as Hugo shortcodes. Components that the Renderer renders
+// as something other than markdown blocks are placed in the copy as RawBlocks.
+func RenderComponents(r Renderer, doc *markdown.Doc) *markdown.Doc {
+	rendered := doc.Clone()
+	markdown.Rewrite(rendered, func(node markdown.Node) markdown.Node { return renderComponent(r, node) })
+	return rendered
+}
+
+// renderInlines renders the inline content with the Renderer, for inline content
+// that is rendered as text rather than as part of a document
+func renderInlines(r Renderer, inlines []markdown.Inline) []markdown.Inline {
+	paragraph := markdown.Rewrite(markdown.Paragraph{Inlines: inlines}, func(node markdown.Node) markdown.Node { return renderComponent(r, node) })
+	return paragraph.(markdown.Paragraph).Inlines
+}
+
+// renderComponent renders the node with the Renderer if it is a component that the Renderer renders
+func renderComponent(r Renderer, node markdown.Node) markdown.Node {
+	var rendered fmt.Stringer
+	switch n := node.(type) {
+	case markdown.Image:
+		return r.Image(n)
+	case markdown.Link:
+		return r.Link(n)
+	case markdown.Header:
+		rendered = r.Heading(n)
+	case markdown.CodeBlock:
+		rendered = r.CodeBlock(n)
+	case markdown.Table:
+		rendered = r.Table(n)
+	case markdown.Admonition:
+		// Collapsible admonitions are rendered as their title followed by their content,
+		// since the Renderer has no way to hide content
+		if n.Collapsible {
+			return n
+		}
+		content := n.Content
+		if content == nil {
+			content = markdown.NewDoc(markdown.DocConfig{})
+		}
+		rendered = r.Admonition(n.Kind, content)
+	default:
+		return node
+	}
+
+	if block, isBlock := rendered.(markdown.Block); isBlock {
+		return block
+	}
+	return markdown.RawBlock{Content: rendered.String()}
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{
+		OutputMarkdown: MarkdownRenderer{},
+		OutputHugo:     HugoRenderer{},
+		OutputJSON:     JSONRenderer{},
+		OutputMDX:      MDXRenderer{},
+		OutputMkDocs:   MkDocsRenderer{},
+	}
+)
+
+// RegisterRenderer makes the Renderer available as an output format by its name,
+// replacing any Renderer already registered with the name.
+func RegisterRenderer(r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[r.Name()] = r
+}
+
+// GetRenderer gets the Renderer registered for the output format
+func GetRenderer(name string) (Renderer, error) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	if r, exists := renderers[name]; exists {
+		return r, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q. Can be %s", name, QuoteChoices(rendererNames()))
+}
+
+// RendererNames are the output formats of the registered Renderers, in alphabetical order
+func RendererNames() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	return rendererNames()
+}
+
+func rendererNames() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// QuoteChoices lists choices, such as the RendererNames, as "'a', 'b', or 'c'"
+func QuoteChoices(choices []string) string {
+	quoted := make([]string, len(choices))
+	for i, choice := range choices {
+		quoted[i] = "'" + choice + "'"
+	}
+	switch len(quoted) {
+	case 0, 1:
+		return strings.Join(quoted, "")
+	case 2:
+		return quoted[0] + " or " + quoted[1]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", or " + quoted[len(quoted)-1]
+}
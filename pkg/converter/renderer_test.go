@@ -0,0 +1,403 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+)
+
+// upperRenderer renders headers in upper case and code without a language
+type upperRenderer struct {
+	MarkdownRenderer
+}
+
+func (upperRenderer) Name() string { return "upper" }
+
+func (upperRenderer) Heading(header markdown.Header) fmt.Stringer {
+	return markdown.Rewrite(header, func(node markdown.Node) markdown.Node {
+		if text, isText := node.(markdown.Text); isText {
+			return markdown.Text{Content: strings.ToUpper(text.Content)}
+		}
+		return node
+	})
+}
+
+func (upperRenderer) CodeBlock(code markdown.CodeBlock) fmt.Stringer {
+	return markdown.CodeBlock{Code: code.Code}
+}
+
+func TestHugoRenderer(t *testing.T) {
+	content := `
+<html>
+	<body>
+		<span id="title-text">Test Doc</span>
+		<div id="main-content">
+			<p><img src="image.png" alt="Image"></p>
+			<div class="confluence-information-macro confluence-information-macro-tip">
+				<div class="confluence-information-macro-body">
+					<p>Tip Panel</p>
+				</div>
+			</div>
+		</div>
+	</body>
+</html>
+`
+
+	result, err := ConvertString(content, WithInputFormat(InputConfluence), WithOutputFormat(OutputHugo))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := `# Test Doc
+
+{{< figure src="./image.png" alt="Image" >}}
+
+{{% notice tip %}}
+Tip Panel
+{{% /notice %}}
+`
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestRenderHugoDocument(t *testing.T) {
+	conv, err := NewConverter(WithOutputFormat(OutputHugo), WithTitleHeading(false))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	mdDoc := conv.DocumentToMarkdown(newTestDoc(`<html><body><p><img src="image.png" alt="Image"></p><table><tr><th>Logo</th></tr><tr><td><img src="logo.png" alt="Logo"></td></tr></table></body></html>`))
+
+	// The images are kept in the document, including the one in the table, until it is rendered
+	var sources []string
+	markdown.Inspect(mdDoc, func(node markdown.Node) bool {
+		if img, isImage := node.(markdown.Image); isImage {
+			sources = append(sources, img.Src)
+		}
+		return true
+	})
+	if result := strings.Join(sources, ","); result != "image.png,logo.png" {
+		t.Errorf("Expected image.png,logo.png. Got %s", result)
+	}
+
+	result, err := Render(conv.Renderer, mdDoc)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	expected := `{{< figure src="./image.png" alt="Image" >}}
+
+| Logo |
+| --- |
+| {{< figure src="./logo.png" alt="Logo" >}} |
+`
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+
+	expected = "![Image](image.png)\n\n| Logo |\n| --- |\n| ![Logo](logo.png) |"
+	if result := mdDoc.String(); result != expected {
+		t.Errorf("Expected the document to be unchanged\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	RegisterRenderer(upperRenderer{})
+
+	if names := strings.Join(RendererNames(), ","); names != "hugo,json,md,mdx,mkdocs,upper" {
+		t.Errorf("Expected hugo,json,md,mdx,mkdocs,upper. Got %s", names)
+	}
+
+	content := `<html><body><h1>Title</h1><pre class="language-go">go test</pre></body></html>`
+	result, err := ConvertString(content, WithOutputFormat("upper"), WithTitleHeading(false), WithReduceHeaders(false))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "# TITLE\n\n```\ngo test\n```\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestGetRendererUnknown(t *testing.T) {
+	_, err := GetRenderer("rst")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if !strings.Contains(err.Error(), "'hugo'") || !strings.Contains(err.Error(), "'md'") {
+		t.Errorf("Expected the output formats in the error. Got %s", err)
+	}
+}
+
+func TestJSONRenderer(t *testing.T) {
+	content := `<html>
+	<body>
+		<h1>Section</h1>
+		<div>
+			<p>Paragraph with a <a href="/link">link</a></p>
+		</div>
+	</body>
+</html>`
+
+	result, err := ConvertString(content, WithOutputFormat(OutputJSON), WithTitleHeading(false))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	mdDoc := &markdown.Doc{}
+	if err := json.Unmarshal([]byte(result), mdDoc); err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "## Section\n\nParagraph with a [link](/link)"
+	if mdDoc.String() != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, mdDoc.String())
+	}
+
+	if pos := mdDoc.Position(0); pos.Line != 3 || pos.Path != "html > body > h1" {
+		t.Errorf("Expected the header on line 3. Got %v", pos)
+	}
+	div, _ := mdDoc.Blocks()[1].(*markdown.Doc)
+	if div == nil || div.Position(0).Line != 5 {
+		t.Errorf("Expected the paragraph in the div on line 5. Got %v", mdDoc.Blocks()[1])
+	}
+}
+
+func TestMDXRenderer(t *testing.T) {
+	content := `
+<html>
+	<body>
+		<span id="title-text">Getting Started</span>
+		<div id="main-content">
+			<p>Use {name} when a &lt; b</p>
+			<p><code>{code}</code></p>
+			<div class="confluence-information-macro confluence-information-macro-note">
+				<div class="confluence-information-macro-body">
+					<p>Warning Panel</p>
+				</div>
+			</div>
+			<div class="confluence-information-macro confluence-information-macro-warning">
+				<div class="confluence-information-macro-body">
+					<p>Error Panel</p>
+				</div>
+			</div>
+		</div>
+	</body>
+</html>
+`
+
+	result, err := ConvertString(content, WithInputFormat(InputConfluence), WithOutputFormat(OutputMDX))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := `---
+title: "Getting Started"
+id: "getting-started"
+---
+
+# Getting Started
+
+Use \{name\} when a \< b
+
+` + "`{code}`" + `
+
+:::warning
+
+Warning Panel
+
+:::
+
+:::danger
+
+Error Panel
+
+:::
+`
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestMDXRendererFrontMatter(t *testing.T) {
+	content := `<html><head><title>Getting Started</title></head><body><p>Content</p></body></html>`
+	tests := map[string]struct {
+		opts     []Option
+		expected string
+	}{
+		"default": {
+			expected: "---\ntitle: \"Getting Started\"\nid: \"getting-started\"\n---\n\n# Getting Started\n\nContent\n",
+		},
+		"yaml": {
+			opts:     []Option{WithFrontMatter(markdown.FrontMatterYAML)},
+			expected: "---\ntitle: \"Getting Started\"\nid: \"getting-started\"\n---\n\n# Getting Started\n\nContent\n",
+		},
+		"none": {
+			opts:     []Option{WithFrontMatter(markdown.FrontMatterNone)},
+			expected: "# Getting Started\n\nContent\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := ConvertString(content, append([]Option{WithOutputFormat(OutputMDX)}, test.opts...)...)
+			if err != nil {
+				t.Fatalf("Expected no error. Got %s", err)
+			}
+			if result != test.expected {
+				t.Errorf("Expected\n%s\nGot\n%s", test.expected, result)
+			}
+		})
+	}
+
+	_, err := ConvertString(content, WithOutputFormat(OutputMDX), WithFrontMatter(markdown.FrontMatterTOML))
+	expected := "the mdx output format only supports yaml front matter. Got toml"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %s. Got %v", expected, err)
+	}
+}
+
+func TestEscapeMDX(t *testing.T) {
+	tests := map[string]struct {
+		content  string
+		expected string
+	}{
+		"braces":          {"a {b} c", `a \{b\} c`},
+		"escaped":         {`a \{ \< b`, `a \{ \< b`},
+		"code span":       {"a `{b}` ``c ` {d}``", "a `{b}` ``c ` {d}``"},
+		"unclosed code":   {"a ` {b}", "a ` \\{b\\}"},
+		"less than":       {"a < b", `a \< b`},
+		"void tag":        {"a<br>b", "a<br />b"},
+		"attributes":      {`<td colspan="2" class="x">a</td>`, `<td colSpan="2" className="x">a</td>`},
+		"closed tag":      {"<img src=\"a.png\"/>", "<img src=\"a.png\"/>"},
+		"comment":         {"a <!-- b */ --> c", "a {/* b * / */} c"},
+		"comment lines":   {"<!-- a\n{b} -->", "{/* a\n{b} */}"},
+		"code block":      {"a {b}\n\n```js\nconst a = {b: 1}\n```\n\n{c}", "a \\{b\\}\n\n```js\nconst a = {b: 1}\n```\n\n\\{c\\}"},
+		"long code fence": {"````\n```\n{a}\n````\n{b}", "````\n```\n{a}\n````\n\\{b\\}"},
+		"quoted code":     {"> ```\n> {a}\n> ```", "> ```\n> {a}\n> ```"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := EscapeMDX(test.content); result != test.expected {
+				t.Errorf("Expected\n%s\nGot\n%s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestMkDocsRenderer(t *testing.T) {
+	content := `
+<html>
+	<body>
+		<span id="title-text">Test Doc</span>
+		<div id="main-content">
+			<div class="confluence-information-macro confluence-information-macro-information">
+				<p class="title">Read This</p>
+				<div class="confluence-information-macro-body">
+					<p>Info Panel</p>
+					<ul><li>Item</li></ul>
+				</div>
+			</div>
+			<div class="expand-container">
+				<div class="expand-control"><span class="expand-control-text">Show details</span></div>
+				<div class="expand-content expand-hidden">
+					<p>Hidden content</p>
+				</div>
+			</div>
+			<div class="code panel pdl">
+				<div class="codeHeader panelHeader pdl"><b>build.sh</b></div>
+				<div class="codeContent panelContent pdl">
+					<pre data-syntaxhighlighter-params="brush: bash; gutter: false">go build</pre>
+				</div>
+			</div>
+		</div>
+	</body>
+</html>
+`
+
+	result, err := ConvertString(content, WithInputFormat(InputConfluence), WithOutputFormat(OutputMkDocs))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := `# Test Doc
+
+!!! info "Read This"
+
+    Info Panel
+
+    * Item
+
+??? note "Show details"
+
+    Hidden content
+
+` + "```bash title=\"build.sh\"\ngo build\n```\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+
+	result, err = ConvertString(content, WithInputFormat(InputConfluence))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	// Standard markdown has no admonitions or code titles, so only the title of the expand macro is kept
+	expected = "# Test Doc\n\nInfo Panel\n\n* Item\n\nShow details\n\nHidden content\n\n```bash\ngo build\n```\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestMkDocsRendererQuotesTitles(t *testing.T) {
+	content := `
+<html>
+	<body>
+		<div id="main-content">
+			<div class="confluence-information-macro confluence-information-macro-note">
+				<p class="title">Say "hi"</p>
+				<div class="confluence-information-macro-body"><p>Hello</p></div>
+			</div>
+			<div class="code panel pdl">
+				<div class="codeHeader panelHeader pdl"><b>say "hi".sh</b></div>
+				<div class="codeContent panelContent pdl"><pre>echo hi</pre></div>
+			</div>
+		</div>
+	</body>
+</html>
+`
+
+	result, err := ConvertString(content, WithInputFormat(InputConfluence), WithOutputFormat(OutputMkDocs))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "!!! warning \"Say 'hi'\"\n\n    Hello\n\n```txt title=\"say 'hi'.sh\"\necho hi\n```\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestQuoteChoices(t *testing.T) {
+	tests := map[string]struct {
+		choices  []string
+		expected string
+	}{
+		"none":  {expected: ""},
+		"one":   {choices: []string{"md"}, expected: "'md'"},
+		"two":   {choices: []string{"md", "hugo"}, expected: "'md' or 'hugo'"},
+		"three": {choices: []string{"md", "hugo", "mdx"}, expected: "'md', 'hugo', or 'mdx'"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := QuoteChoices(test.choices); result != test.expected {
+				t.Errorf("Expected %s. Got %s", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package converter
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"gopkg.in/yaml.v3"
+)
+
+// RewriteAction is how a RewriteRule changes the elements it matches
+type RewriteAction string
+
+const (
+	// RewriteRemove removes the element and its content
+	RewriteRemove RewriteAction = "remove"
+	// RewriteUnwrap replaces the element with its content
+	RewriteUnwrap RewriteAction = "unwrap"
+	// RewriteReplace replaces the element with an element with the tag in With, keeping its content and attributes
+	RewriteReplace RewriteAction = "replace"
+	// RewriteComponent converts the element to the markdown component in Component
+	RewriteComponent RewriteAction = "component"
+)
+
+const (
+	// ComponentParagraph converts the element to a paragraph
+	ComponentParagraph = "paragraph"
+	// ComponentBlockquote converts the element to a blockquote
+	ComponentBlockquote = "blockquote"
+	// ComponentCode converts the text of the element to a code block, in the rule's Language
+	ComponentCode = "code"
+	// ComponentHeading converts the element to a header, of the rule's Level
+	ComponentHeading = "heading"
+	// ComponentRule replaces the element with a horizontal rule
+	ComponentRule = "rule"
+)
+
+// tagPattern matches the name of an HTML element
+var tagPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
+
+// RewriteRule changes the elements that match the CSS selector before the document is converted.
+// For example, a rule can remove "div.breadcrumbs", replace "span.kbd" with "kbd", or convert
+// "div.warning-box" to a blockquote.
+type RewriteRule struct {
+	Selector  string        `yaml:"selector" json:"selector"`
+	Action    RewriteAction `yaml:"action" json:"action"`
+	With      string        `yaml:"with,omitempty" json:"with,omitempty"`
+	Component string        `yaml:"component,omitempty" json:"component,omitempty"`
+	Language  string        `yaml:"language,omitempty" json:"language,omitempty"`
+	Level     int           `yaml:"level,omitempty" json:"level,omitempty"`
+}
+
+// Rewriter applies RewriteRules to documents, in the order of the rules
+type Rewriter struct {
+	rules    []RewriteRule
+	matchers []cascadia.Selector
+}
+
+// NewRewriter creates a Rewriter for the rules, and checks that each rule is valid
+func NewRewriter(rules []RewriteRule) (*Rewriter, error) {
+	r := &Rewriter{}
+	for i, rule := range rules {
+		matcher, err := cascadia.Compile(rule.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid selector %q: %w", i+1, rule.Selector, err)
+		}
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i+1, rule.Selector, err)
+		}
+		r.rules = append(r.rules, rule)
+		r.matchers = append(r.matchers, matcher)
+	}
+	return r, nil
+}
+
+// LoadRewriteRules reads a YAML list of rules, such as
+//
+//   - selector: div.warning-box
+//     action: component
+//     component: blockquote
+//   - selector: .breadcrumbs
+//     action: remove
+func LoadRewriteRules(r io.Reader) ([]RewriteRule, error) {
+	var rules []RewriteRule
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&rules); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if _, err := NewRewriter(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// validate checks that the rule has what its action needs
+func (rule RewriteRule) validate() error {
+	switch rule.Action {
+	case RewriteRemove, RewriteUnwrap:
+	case RewriteReplace:
+		if !tagPattern.MatchString(replacementTag(rule.With)) {
+			return fmt.Errorf("replace needs the tag of an element in 'with'. Got %q", rule.With)
+		}
+	case RewriteComponent:
+		switch rule.Component {
+		case ComponentParagraph, ComponentBlockquote, ComponentCode, ComponentRule:
+		case ComponentHeading:
+			if rule.Level < 0 || rule.Level > 6 {
+				return fmt.Errorf("heading level must be between 1 and 6. Got %d", rule.Level)
+			}
+		default:
+			return fmt.Errorf("unknown component %q. Can be '%s', '%s', '%s', '%s', or '%s'", rule.Component,
+				ComponentParagraph, ComponentBlockquote, ComponentCode, ComponentHeading, ComponentRule)
+		}
+	default:
+		return fmt.Errorf("unknown action %q. Can be '%s', '%s', '%s', or '%s'", rule.Action,
+			RewriteRemove, RewriteUnwrap, RewriteReplace, RewriteComponent)
+	}
+	return nil
+}
+
+// Rewrite applies each rule to the elements in the selection that match it
+func (r *Rewriter) Rewrite(s *goquery.Selection) {
+	if r == nil {
+		return
+	}
+	for i, rule := range r.rules {
+		for _, node := range s.FindMatcher(r.matchers[i]).Nodes {
+			// An element may have been removed along with an element matched before it
+			if node.Parent != nil {
+				rule.apply(node)
+			}
+		}
+	}
+}
+
+// apply changes the element as the rule says
+func (rule RewriteRule) apply(node *html.Node) {
+	switch rule.Action {
+	case RewriteRemove:
+		node.Parent.RemoveChild(node)
+	case RewriteUnwrap:
+		for child := node.FirstChild; child != nil; child = node.FirstChild {
+			node.RemoveChild(child)
+			node.Parent.InsertBefore(child, node)
+		}
+		node.Parent.RemoveChild(node)
+	case RewriteReplace:
+		renameNode(node, strings.ToLower(replacementTag(rule.With)))
+	case RewriteComponent:
+		rule.applyComponent(node)
+	}
+}
+
+// applyComponent changes the element to the element that is converted to the component
+func (rule RewriteRule) applyComponent(node *html.Node) {
+	switch rule.Component {
+	case ComponentParagraph:
+		renameNode(node, "p")
+	case ComponentBlockquote:
+		renameNode(node, "blockquote")
+	case ComponentHeading:
+		level := rule.Level
+		if level == 0 {
+			level = 2
+		}
+		renameNode(node, fmt.Sprintf("h%d", level))
+	case ComponentCode:
+		code := codeText(node)
+		for child := node.FirstChild; child != nil; child = node.FirstChild {
+			node.RemoveChild(child)
+		}
+		node.AppendChild(&html.Node{Type: html.TextNode, Data: code})
+		renameNode(node, "pre")
+		node.Attr = nil
+		if rule.Language != "" {
+			node.Attr = []html.Attribute{{Key: "data-lang", Val: rule.Language}}
+		}
+	case ComponentRule:
+		for child := node.FirstChild; child != nil; child = node.FirstChild {
+			node.RemoveChild(child)
+		}
+		renameNode(node, "hr")
+	}
+}
+
+// replacementTag gets the tag from the "with" of a replace rule, which may be written as "<kbd>"
+func replacementTag(with string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(with), "<"), ">")
+}
+
+// renameNode changes the tag of the element
+func renameNode(node *html.Node, tag string) {
+	node.Data = tag
+	node.DataAtom = atom.Lookup([]byte(tag))
+}
+
+// codeText gets the text of the node, with a newline for each "br"
+func codeText(node *html.Node) string {
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			text.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.Data == "br":
+			text.WriteString("\n")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return strings.Trim(text.String(), "\n")
+}
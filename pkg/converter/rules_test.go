@@ -0,0 +1,81 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+const rewriteTestHTML = `
+<html>
+	<body>
+		<div class="breadcrumbs"><a href="/">Home</a></div>
+		<div class="warning-box"><p>Be careful</p></div>
+		<p>Press <span class="kbd">Ctrl</span> to <span class="wrapper"><em>continue</em></span></p>
+		<div class="snippet">go build<br>go test</div>
+		<div class="title">Section</div>
+		<div class="divider">ignored</div>
+	</body>
+</html>
+`
+
+const rewriteTestRules = `
+- selector: .breadcrumbs
+  action: remove
+- selector: div.warning-box
+  action: component
+  component: blockquote
+- selector: span.kbd
+  action: replace
+  with: <code>
+- selector: span.wrapper
+  action: unwrap
+- selector: div.snippet
+  action: component
+  component: code
+  language: sh
+- selector: div.title
+  action: component
+  component: heading
+  level: 3
+- selector: div.divider
+  action: component
+  component: rule
+`
+
+func TestRewriteRules(t *testing.T) {
+	rules, err := LoadRewriteRules(strings.NewReader(rewriteTestRules))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	result, err := ConvertString(rewriteTestHTML, WithRewriteRules(rules...), WithTitleHeading(false), WithReduceHeaders(false))
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := "> Be careful\n\n" +
+		"Press `Ctrl` to _continue_\n\n" +
+		"```sh\ngo build\ngo test\n```\n\n" +
+		"### Section\n\n" +
+		"---\n"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestInvalidRewriteRules(t *testing.T) {
+	tests := []string{
+		"- selector: div\n  action: rename\n",
+		"- selector: div\n  action: replace\n",
+		"- selector: div\n  action: component\n  component: table\n",
+		"- selector: div\n  action: component\n  component: heading\n  level: 7\n",
+		"- selector: '[['\n  action: remove\n",
+		"- selector: div\n  action: remove\n  unknown: true\n",
+	}
+
+	for _, test := range tests {
+		if _, err := LoadRewriteRules(strings.NewReader(test)); err == nil {
+			t.Errorf("Expected an error for\n%s", test)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package converter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// SpanFill is how the cells covered by a "colspan" or "rowspan" are filled,
+// since a markdown table cannot have cells that span multiple columns or rows.
+type SpanFill int
+
+const (
+	// SpanFillBlank leaves the covered cells empty
+	SpanFillBlank SpanFill = iota
+	// SpanFillRepeat repeats the content of the spanning cell in each covered cell
+	SpanFillRepeat
+)
+
+// ParseSpanFill converts the name of a SpanFill, either "blank" or "repeat", to a SpanFill.
+func ParseSpanFill(name string) (SpanFill, error) {
+	switch strings.ToLower(name) {
+	case "", "blank":
+		return SpanFillBlank, nil
+	case "repeat":
+		return SpanFillRepeat, nil
+	}
+	return SpanFillBlank, fmt.Errorf("unknown span fill %q. Can be 'blank' or 'repeat'", name)
+}
+
+const (
+	// tableBlockPattern matches content in a table that a markdown table cannot represent
+	tableBlockPattern = "table,ul,ol,dl,pre,blockquote,h1,h2,h3,h4,h5,h6,hr"
+
+	// maxSpan limits how many cells a single cell can span
+	maxSpan = 1000
+)
+
+var (
+	textAlignPattern = regexp.MustCompile(`(?i)text-align\s*:\s*(left|center|right)`)
+
+	// rawHTMLTags are the elements that are kept when an element is rendered as HTML.
+	// Other elements, such as embedded content and forms, are left out along with their content.
+	rawHTMLTags = map[string]bool{
+		"a": true, "abbr": true, "address": true, "article": true, "aside": true, "b": true, "bdi": true, "bdo": true,
+		"big": true, "blockquote": true, "br": true, "caption": true, "center": true, "cite": true, "code": true,
+		"col": true, "colgroup": true, "dd": true, "del": true, "details": true, "dfn": true, "div": true, "dl": true,
+		"dt": true, "em": true, "figcaption": true, "figure": true, "font": true, "footer": true, "h1": true, "h2": true,
+		"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "i": true, "img": true, "ins": true,
+		"kbd": true, "li": true, "main": true, "mark": true, "nav": true, "ol": true, "p": true, "pre": true, "q": true,
+		"rp": true, "rt": true, "ruby": true, "s": true, "samp": true, "section": true, "small": true, "span": true,
+		"strike": true, "strong": true, "sub": true, "summary": true, "sup": true, "table": true, "tbody": true,
+		"td": true, "tfoot": true, "th": true, "thead": true, "time": true, "tr": true, "tt": true, "u": true,
+		"ul": true, "var": true, "wbr": true,
+	}
+
+	// rawHTMLAttrs are the attributes that are kept when an element is rendered as HTML
+	rawHTMLAttrs = map[string]bool{
+		"colspan": true, "rowspan": true, "align": true, "href": true, "src": true, "alt": true, "title": true,
+	}
+
+	// rawHTMLURLAttrs are the attributes of rawHTMLAttrs that are URLs, which are only kept if they are safe
+	rawHTMLURLAttrs = map[string]bool{"href": true, "src": true}
+
+	// rawHTMLSchemes are the URL schemes that are safe to keep when an element is rendered as HTML.
+	// URLs without a scheme, such as relative paths and fragments, are also safe.
+	rawHTMLSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+	// rawHTMLVerbatimTags are the elements of rawHTMLTags whose whitespace is kept when they are rendered as HTML
+	rawHTMLVerbatimTags = map[string]bool{"pre": true}
+)
+
+// ToTableOrHTML transforms the "table" dom element to a markdown Table.
+// If the table contains nested tables or other blocks such as lists,
+// then it cannot be represented as a markdown table, and is rendered as HTML instead.
+func (t *Transformer) ToTableOrHTML(table *goquery.Selection) markdown.Block {
+	if len(table.Find(tableBlockPattern).Nodes) > 0 {
+		t.report(DiagnosticLossy, table.Nodes[0], "the table contains nested tables or blocks, so it is rendered as HTML")
+		return t.ToRawHTML(table)
+	}
+	return t.ToTable(table)
+}
+
+// ToTable transforms the "table" dom element to a markdown Table.
+// If the table has no header row, then its first row is used as the header.
+// Cells that span multiple columns or rows are expanded into multiple cells,
+// which are filled according to the SpanFill of the Transformer.
+// The alignment of each column is taken from the "align" attribute or
+// "text-align" style of the first cell in the column that has one.
+func (t *Transformer) ToTable(table *goquery.Selection) markdown.Table {
+	headerRow, rowElms := t.getTableRows(table)
+
+	var trs []*goquery.Selection
+	if headerRow != nil {
+		trs = append(trs, headerRow)
+	}
+	for _, tr := range rowElms {
+		if len(tr.ChildrenFiltered("th,td").Nodes) > 0 {
+			trs = append(trs, tr)
+		}
+	}
+
+	grid := make([][]markdown.TableCell, len(trs))
+	filled := make([][]bool, len(trs))
+	var alignments []markdown.Alignment
+	alignmentSet := map[int]bool{}
+
+	fill := func(row int, col int, content markdown.TableCell) {
+		for len(grid[row]) <= col {
+			grid[row] = append(grid[row], nil)
+			filled[row] = append(filled[row], false)
+		}
+		grid[row][col] = content
+		filled[row][col] = true
+	}
+
+	for r, tr := range trs {
+		col := 0
+		tr.ChildrenFiltered("th,td").Each(func(i int, cell *goquery.Selection) {
+			for col < len(filled[r]) && filled[r][col] {
+				col++
+			}
+
+			content := t.tableCell(cell)
+			colspan := cellSpan(cell, "colspan", maxSpan)
+			rowspan := cellSpan(cell, "rowspan", len(trs)-r)
+			if colspan > 1 || rowspan > 1 {
+				t.report(DiagnosticLossy, cell.Nodes[0], fmt.Sprintf("the cell spans %d columns and %d rows, which are expanded into separate cells", colspan, rowspan))
+			}
+
+			if align := cellAlignment(cell); align != markdown.AlignDefault && !alignmentSet[col] {
+				for len(alignments) <= col {
+					alignments = append(alignments, markdown.AlignDefault)
+				}
+				alignments[col] = align
+				alignmentSet[col] = true
+			}
+
+			for dr := 0; dr < rowspan; dr++ {
+				for dc := 0; dc < colspan; dc++ {
+					if (dr == 0 && dc == 0) || t.spanFill == SpanFillRepeat {
+						fill(r+dr, col+dc, content)
+					} else {
+						fill(r+dr, col+dc, nil)
+					}
+				}
+			}
+			col += colspan
+		})
+	}
+
+	// A markdown table must have a header row, so without one the first row is used
+	var mdTable markdown.Table
+	if len(grid) > 0 {
+		mdTable.Headers = grid[0]
+		grid = grid[1:]
+	}
+	mdTable.Rows = grid
+	mdTable.Alignments = alignments
+
+	return mdTable
+}
+
+// ToRawHTML renders the dom element as HTML, for content that cannot be represented in markdown.
+// Elements that are not safe to embed, such as scripts, iframes, objects, and forms, are left out along
+// with their content. Comments and most attributes are also left out, as are links and sources with
+// URLs that are not http, https, mailto, or relative, such as "javascript:" URLs.
+// Blank lines are also left out, since a blank line ends a block of HTML in markdown.
+// Newlines in verbatim elements, such as "pre", are kept as "&#10;" so their lines are not changed.
+func (t *Transformer) ToRawHTML(elm *goquery.Selection) markdown.RawHTML {
+	var rendered strings.Builder
+	for _, node := range elm.Nodes {
+		if clone := cloneRawHTML(node, false); clone != nil {
+			_ = html.Render(&rendered, clone)
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(rendered.String(), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+	return markdown.RawHTML{Content: strings.Join(lines, "\n")}
+}
+
+// getTableRows finds the row to use as the header of the table, and the rest of the rows.
+// The header is the first row of "thead", or the first row of the table if it only contains "th" cells.
+// Only one header row is supported in markdown, so any other rows of "thead" are left out.
+func (t *Transformer) getTableRows(table *goquery.Selection) (headerRow *goquery.Selection, rows []*goquery.Selection) {
+	thead := table.ChildrenFiltered("thead").First()
+	if len(thead.Nodes) > 0 {
+		theadRows := thead.ChildrenFiltered("tr")
+		headerRow = theadRows.First()
+		if len(headerRow.Nodes) == 0 {
+			headerRow = thead
+		}
+		theadRows.Each(func(i int, tr *goquery.Selection) {
+			if i > 0 {
+				t.report(DiagnosticLossy, tr.Nodes[0], "markdown tables have one header row, so the other header rows are left out")
+			}
+		})
+	}
+
+	// Footer rows are always placed last, wherever "tfoot" is in the table
+	addRows := func(i int, section *goquery.Selection) {
+		trs := section
+		if goquery.NodeName(section) != "tr" {
+			trs = section.ChildrenFiltered("tr")
+		}
+		trs.Each(func(j int, tr *goquery.Selection) {
+			rows = append(rows, tr)
+		})
+	}
+	table.ChildrenFiltered("tr,tbody").Each(addRows)
+	table.ChildrenFiltered("tfoot").Each(addRows)
+
+	if headerRow == nil && len(rows) > 0 {
+		cells := rows[0].ChildrenFiltered("th,td")
+		if len(cells.Nodes) > 0 && len(cells.Filter("td").Nodes) == 0 {
+			headerRow = rows[0]
+			rows = rows[1:]
+		}
+	}
+
+	return
+}
+
+// tableCell converts the content of the cell to its inline markdown.
+// A cell must be on a single line, so paragraphs in the cell are separated by line breaks.
+func (t *Transformer) tableCell(cell *goquery.Selection) markdown.TableCell {
+	var inlines []markdown.Inline
+	breakNext := false
+	for _, node := range cell.Nodes {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			isBlock := child.Type == html.ElementNode && (child.Data == "p" || child.Data == "div")
+			var content []markdown.Inline
+			if isBlock {
+				content = t.childrenToInlines(child)
+			} else {
+				content = t.nodeToInlines(child)
+			}
+
+			blank := true
+			for _, inline := range content {
+				if !markdown.IsBlankInline(inline) {
+					blank = false
+				}
+			}
+			if blank && (isBlock || breakNext || len(inlines) == 0) {
+				continue
+			}
+
+			if (isBlock || breakNext) && len(inlines) > 0 {
+				inlines = append(inlines, markdown.LineBreak{})
+			}
+			inlines = append(inlines, content...)
+			breakNext = isBlock
+		}
+	}
+	return inlines
+}
+
+// cellSpan gets the number of cells spanned from the attribute, up to the limit.
+// A rowspan of 0 spans all the remaining rows.
+func cellSpan(cell *goquery.Selection, attr string, limit int) int {
+	value, exists := cell.Attr(attr)
+	if !exists {
+		return 1
+	}
+	span, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || span < 0 || (span == 0 && attr != "rowspan") {
+		return 1
+	}
+	if span == 0 || span > limit {
+		return max(limit, 1)
+	}
+	return span
+}
+
+// cellAlignment gets the alignment of the cell from the "align" attribute or "text-align" style
+func cellAlignment(cell *goquery.Selection) markdown.Alignment {
+	align := strings.ToLower(strings.TrimSpace(cell.AttrOr("align", "")))
+	if match := textAlignPattern.FindStringSubmatch(cell.AttrOr("style", "")); match != nil {
+		align = strings.ToLower(match[1])
+	}
+
+	switch align {
+	case "left":
+		return markdown.AlignLeft
+	case "center":
+		return markdown.AlignCenter
+	case "right":
+		return markdown.AlignRight
+	}
+	return markdown.AlignDefault
+}
+
+// isSafeURL checks whether the URL is relative, or has one of the rawHTMLSchemes
+func isSafeURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || rawHTMLSchemes[strings.ToLower(u.Scheme)]
+}
+
+// cloneRawHTML copies the node and its children, leaving out the content
+// and attributes that should not be kept when rendering the node as HTML.
+// Text within a verbatim element is escaped as raw HTML, with its newlines as "&#10;".
+func cloneRawHTML(node *html.Node, verbatim bool) *html.Node {
+	switch node.Type {
+	case html.CommentNode:
+		return nil
+	case html.ElementNode:
+		if !rawHTMLTags[node.Data] {
+			return nil
+		}
+		verbatim = verbatim || rawHTMLVerbatimTags[node.Data]
+	case html.TextNode:
+		if verbatim {
+			return &html.Node{Type: html.RawNode, Data: strings.ReplaceAll(html.EscapeString(node.Data), "\n", "&#10;")}
+		}
+	}
+
+	clone := &html.Node{Type: node.Type, DataAtom: node.DataAtom, Data: node.Data, Namespace: node.Namespace}
+	for _, attr := range node.Attr {
+		if rawHTMLAttrs[attr.Key] && (!rawHTMLURLAttrs[attr.Key] || isSafeURL(attr.Val)) {
+			clone.Attr = append(clone.Attr, attr)
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if childClone := cloneRawHTML(child, verbatim); childClone != nil {
+			clone.AppendChild(childClone)
+		}
+	}
+	return clone
+}
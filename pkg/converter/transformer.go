@@ -3,33 +3,67 @@ package converter
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/david-mk-lawrence/htmltomd/pkg/markdown"
 
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
 )
 
-var asciiFilter = regexp.MustCompile("[[:^ascii:]]")
+var (
+	asciiFilter       = regexp.MustCompile("[[:^ascii:]]")
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+const (
+	defaultAsciiOnly = false
+
+	// escapedAttr marks an element whose text has already been escaped,
+	// so that text is not escaped again when its children are converted.
+	escapedAttr = "data-htmltomd-escaped"
+
+	// verbatimPattern matches elements whose text is rendered as is
+	verbatimPattern = "pre,code,script,style"
+)
 
-const defaultAsciiOnly = false
+var (
+	verbatimTags = map[string]bool{"pre": true, "code": true, "script": true, "style": true}
+
+	// codeLangPrefixes are the class prefixes commonly used by syntax highlighters
+	// to specify the language of a code block
+	codeLangPrefixes = []string{"language-", "lang-", "highlight-"}
+)
 
 // SelectionCallback is a function that handles a goquery.Selection
 type SelectionCallback = func(i int, s *goquery.Selection)
 
 // Transformer converts HTML DOM elements into markdown elements
 type Transformer struct {
-	format      string
+	renderer    Renderer
 	textCleaner *TextCleaner
+	spanFill    SpanFill
+	diagnostics *Diagnostics
+	policy      ElementPolicy
+	failureMu   sync.Mutex
+	failure     error
 }
 
 // TransformerConf is the configuration for a Transformer.
 type TransformerConf struct {
-	Format      *string
+	// Renderer renders the markdown components of text, such as from InlineText, and defaults to a MarkdownRenderer
+	Renderer    Renderer
 	TextCleaner *TextCleaner
+	SpanFill    *SpanFill
+	// Diagnostics records the content that is left out or loses formatting
+	Diagnostics *Diagnostics
+	// ElementPolicy decides what to do with inline elements that markdown has no formatting for, such as "u"
+	ElementPolicy ElementPolicy
 }
 
-// NewTransformer initializes a Transformer with the given format and TextCleaner.
+// NewTransformer initializes a Transformer with the given Renderer, TextCleaner, SpanFill, Diagnostics, and ElementPolicy.
 func NewTransformer(conf *TransformerConf) *Transformer {
 	var cleaner *TextCleaner
 	if conf != nil && conf.TextCleaner != nil {
@@ -37,12 +71,28 @@ func NewTransformer(conf *TransformerConf) *Transformer {
 	} else {
 		cleaner = NewTextCleaner(nil)
 	}
-	var format string
-	if conf != nil && conf.Format != nil {
-		format = *conf.Format
+	var renderer Renderer = MarkdownRenderer{}
+	if conf != nil && conf.Renderer != nil {
+		renderer = conf.Renderer
+	}
+	spanFill := SpanFillBlank
+	if conf != nil && conf.SpanFill != nil {
+		spanFill = *conf.SpanFill
 	}
 
-	return &Transformer{format: format, textCleaner: cleaner}
+	var diagnostics *Diagnostics
+	var policy ElementPolicy
+	if conf != nil {
+		diagnostics = conf.Diagnostics
+		policy = conf.ElementPolicy
+	}
+
+	return &Transformer{renderer: renderer, textCleaner: cleaner, spanFill: spanFill, diagnostics: diagnostics, policy: policy}
+}
+
+// Renderer is the Renderer of the markdown components
+func (t *Transformer) Renderer() Renderer {
+	return t.renderer
 }
 
 // CleanText is a wrapper for its TextCleaner method.
@@ -67,6 +117,17 @@ func (t *Transformer) Transform(pattern string, elm *goquery.Selection, callback
 	})
 }
 
+// transformInline is like Transform, but skips any elements within "pre"
+// elements since their content is rendered as is. It also skips elements
+// within other inline elements, which are converted along with their parent.
+func (t *Transformer) transformInline(pattern string, elm *goquery.Selection, callbacks ...SelectionCallback) {
+	elm.Find(pattern).FilterFunction(func(i int, s *goquery.Selection) bool {
+		return len(s.ParentsFiltered("pre,"+inlinePattern).Nodes) == 0
+	}).Each(func(i int, s *goquery.Selection) {
+		t.Transforms(i, s, callbacks...)
+	})
+}
+
 // Transforms calls each callback on the given DOM element.
 func (t *Transformer) Transforms(i int, s *goquery.Selection, callbacks ...SelectionCallback) {
 	for _, cb := range callbacks {
@@ -75,169 +136,309 @@ func (t *Transformer) Transforms(i int, s *goquery.Selection, callbacks ...Selec
 }
 
 // ToList transforms the "ul" or "ol" dom element to a markdown List.
+// Nested lists and other block content in each "li" are kept as child blocks of the item.
 func (t *Transformer) ToList(list *goquery.Selection) markdown.List {
-	var items []string
+	var items []markdown.ListItem
 	tag := list.Nodes[0].Data
 	list.ChildrenFiltered("li").Each(func(i int, li *goquery.Selection) {
-		items = append(items, t.textCleaner.CleanText(li.Text()))
+		items = append(items, t.toListItem(li))
 	})
 
 	if tag == "ol" {
-		return markdown.NewOrderedList(items)
+		return markdown.NewOrderedListFromItems(items)
 	}
-	return markdown.NewUnorderedList(items)
+	return markdown.NewUnorderedListFromItems(items)
 }
 
-// ToTable transforms the "table" dom element to a markdown Table.
-func (t *Transformer) ToTable(table *goquery.Selection) markdown.Table {
-	headerElms := getTableHeaders(table)
-	rowElms := getTableRows(table)
+// toListItem converts the "li" dom element to a ListItem.
+// Text before the first block becomes the content of the item, and
+// any text after a block becomes a paragraph.
+func (t *Transformer) toListItem(li *goquery.Selection) markdown.ListItem {
+	var item markdown.ListItem
+	for _, block := range t.toBlocks(li) {
+		// The first paragraph of an item is its content
+		if p, isParagraph := block.(markdown.Paragraph); isParagraph && len(item.Text()) == 0 && len(item.Blocks) == 0 {
+			item.Content, item.Inlines = p.Content, p.Inlines
+			continue
+		}
+		item.Blocks = append(item.Blocks, block)
+	}
+	return item
+}
 
-	headers := make([]string, len(headerElms.Nodes))
-	headerElms.Each(func(i int, th *goquery.Selection) {
-		headers[i] = t.textCleaner.CleanText(th.Text())
-	})
+// toBlocks converts the content of the dom element, such as a "li", to blocks.
+// Lists, paragraphs, code, tables, and blockquotes are converted to their blocks,
+// divs are searched for more blocks, and the text between blocks becomes paragraphs.
+func (t *Transformer) toBlocks(elm *goquery.Selection) []markdown.Block {
+	var blocks []markdown.Block
+	var inlineNodes []*html.Node
 
-	var rows [][]string
+	addBlock := func(block markdown.Block) {
+		if p, isParagraph := block.(markdown.Paragraph); isParagraph && len(p.Text()) == 0 {
+			return
+		}
+		blocks = append(blocks, block)
+	}
+	flushText := func() {
+		addBlock(markdown.Paragraph{Inlines: t.nodesToInlines(inlineNodes)})
+		inlineNodes = nil
+	}
 
-	rowElms.Each(func(i int, tr *goquery.Selection) {
-		cellElms := tr.Find("td")
-		cells := make([]string, len(cellElms.Nodes))
-		cellElms.Each(func(j int, td *goquery.Selection) {
-			cells[j] = t.textCleaner.CleanText(td.Text())
+	var collect func(*goquery.Selection)
+	collect = func(elm *goquery.Selection) {
+		elm.Contents().Each(func(i int, child *goquery.Selection) {
+			switch goquery.NodeName(child) {
+			case "ul", "ol":
+				flushText()
+				addBlock(t.ToList(child))
+			case "p":
+				flushText()
+				addBlock(markdown.Paragraph{Inlines: t.ToInlines(child)})
+			case "pre":
+				flushText()
+				addBlock(t.ToCodeBlock(child))
+			case "table":
+				flushText()
+				addBlock(t.ToTableOrHTML(child))
+			case "blockquote":
+				flushText()
+				addBlock(t.ToBlockquote(child, markdown.DocConfig{}, t.blocksToDoc))
+			case "div":
+				// Divs only group content, so search through them for more blocks
+				flushText()
+				collect(child)
+				flushText()
+			default:
+				inlineNodes = append(inlineNodes, child.Nodes...)
+			}
 		})
-		if len(cells) > 0 {
-			rows = append(rows, cells)
-		}
-	})
+	}
+	collect(elm)
+	flushText()
 
-	return markdown.Table{Headers: headers, Rows: rows}
+	return blocks
 }
 
-func getTableHeaders(table *goquery.Selection) (headerElms *goquery.Selection) {
-	thead := table.Find("thead")
-	if len(thead.Nodes) > 0 {
-		var headerRoot *goquery.Selection
+// blocksToDoc is a SelectionToMD that converts the dom element to a document of its blocks
+func (t *Transformer) blocksToDoc(elm *goquery.Selection, docConf markdown.DocConfig) *markdown.Doc {
+	doc := markdown.NewDoc(docConf)
+	for _, block := range t.toBlocks(elm) {
+		doc.AddContent(block)
+	}
+	return doc
+}
 
-		firstRow := thead.Find("tr").First()
-		if len(firstRow.Nodes) > 0 {
-			headerRoot = firstRow
-		} else {
-			headerRoot = thead
-		}
+// ToBlockquote transforms the "blockquote" dom element to a markdown Blockquote.
+// The content of the quote is converted with toMD, since it may contain any other blocks.
+// Text between the blocks of the quote is wrapped in paragraphs first, so that it is kept
+// in document order. If nothing else is converted, then the text is quoted as a paragraph.
+func (t *Transformer) ToBlockquote(quote *goquery.Selection, docConf markdown.DocConfig, toMD SelectionToMD) markdown.Blockquote {
+	t.WrapLooseText(quote)
+	doc := toMD(quote, docConf)
+	if len(doc.Content()) == 0 {
+		doc.AddParagraphInlines(t.ToInlines(quote))
+	}
 
-		headerElms = headerRoot.Find("th")
-		if len(headerElms.Nodes) == 0 {
-			headerElms = headerRoot.Find("td")
-		}
-	} else {
-		headerElms = table.Find("th")
+	return markdown.Blockquote{Content: doc}
+}
+
+// ToCodeBlock transforms the "pre" dom element to a markdown CodeBlock.
+// The whitespace in the code is preserved. The language is detected from
+// the "pre" element or a "code" element inside it.
+func (t *Transformer) ToCodeBlock(pre *goquery.Selection) markdown.CodeBlock {
+	code := pre.Text()
+	// The closing fence is placed on its own line, so a final newline is redundant
+	code = strings.TrimSuffix(code, "\n")
+
+	lang := findCodeLang(pre.ChildrenFiltered("code").First())
+	if lang == "" {
+		lang = findCodeLang(pre)
 	}
 
-	return
+	return markdown.CodeBlock{Lang: lang, Code: code}
 }
 
-func getTableRows(table *goquery.Selection) (rowElms *goquery.Selection) {
-	tbody := table.Find("tbody")
-	if len(tbody.Nodes) > 0 {
-		rowElms = tbody.Find("tr")
-	} else {
-		rowElms = table.Find("tr")
+// findCodeLang finds the language from the "data-lang" attribute, or from classes
+// such as "language-go", "lang-go", or "highlight-go".
+func findCodeLang(elm *goquery.Selection) string {
+	if len(elm.Nodes) == 0 {
+		return ""
 	}
-	return
+	if lang, exists := elm.Attr("data-lang"); exists && strings.TrimSpace(lang) != "" {
+		return strings.TrimSpace(lang)
+	}
+
+	class, _ := elm.Attr("class")
+	for _, className := range strings.Fields(class) {
+		for _, prefix := range codeLangPrefixes {
+			if lang := strings.TrimPrefix(className, prefix); lang != className && lang != "" {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+// EscapeText escapes markdown characters in the text within the DOM element.
+// Text in code is not escaped, and neither is text in inline elements such as links,
+// since their text is escaped when they are converted.
+// Text is only ever escaped once, even when called again on a child element.
+func (t *Transformer) EscapeText(elm *goquery.Selection) {
+	if len(elm.Closest("["+escapedAttr+"]").Nodes) > 0 {
+		return
+	}
+
+	var escape func(*html.Node)
+	escape = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.TextNode:
+				child.Data = markdown.EscapeInline(child.Data)
+			case html.ElementNode:
+				if !verbatimTags[child.Data] && !inlineTags[child.Data] {
+					escape(child)
+				}
+			}
+		}
+	}
+
+	elm.Each(func(i int, s *goquery.Selection) {
+		if len(s.Closest(verbatimPattern+","+inlinePattern).Nodes) == 0 {
+			escape(s.Nodes[0])
+		}
+	})
+	elm.SetAttr(escapedAttr, "")
 }
 
-// ReplaceAll runs all the default replacement functions
+// ReplaceAll replaces all inline elements in place with markdown.
+// Text outside of the inline elements is escaped so that it is not mistaken for markdown.
 func (t *Transformer) ReplaceAll(elm *goquery.Selection) {
-	t.ReplaceBolds(elm)
-	t.ReplaceItalics(elm)
-	t.ReplaceAnchors(elm)
-	t.ReplaceInlineCodes(elm)
-	t.ReplaceImages(elm)
+	t.EscapeText(elm)
+	t.transformInline(inlinePattern, elm, t.replaceInline)
 }
 
 // ReplaceAnchors finds all child "a" tags and replaces them in place with markdown links.
 func (t *Transformer) ReplaceAnchors(elm *goquery.Selection) {
-	t.Transform("a", elm, t.ReplaceAnchor)
+	t.transformInline("a", elm, t.ReplaceAnchor)
 }
 
 // ReplaceAnchor replaces the DOM element in place with a markdown link.
 func (t *Transformer) ReplaceAnchor(i int, s *goquery.Selection) {
-	if href, exists := s.Attr("href"); exists {
-		text := t.textCleaner.CleanText(s.Text())
-		s.ReplaceWithHtml(fmt.Sprintf("[%s](%s)", text, href))
+	if _, exists := s.Attr("href"); exists {
+		t.replaceInline(i, s)
 	}
 }
 
 // ReplaceImages finds all child "img" tags and replaces them in place with markdown image links.
 func (t *Transformer) ReplaceImages(elm *goquery.Selection) {
-	t.Transform("img", elm, t.ReplaceImage)
+	t.transformInline("img", elm, t.ReplaceImage)
 }
 
-// ReplaceImage replaces the DOM element in place with a markdown image link.
-// If the Transformer is rendering for Hugo, then will replace with a Hugo figure shortcode.
+// ReplaceImage replaces the DOM element in place with a markdown image link,
+// as rendered by the Renderer of the Transformer.
 func (t *Transformer) ReplaceImage(i int, s *goquery.Selection) {
-	if src, exists := s.Attr("src"); exists {
-		alt, _ := s.Attr("alt")
-		if t.format == "hugo" {
-			s.ReplaceWithHtml(fmt.Sprintf("{{< figure src=\"./%s\" alt=\"%s\" >}}", src, alt))
-		} else {
-			s.ReplaceWithHtml(fmt.Sprintf("![%s](%s)", alt, src))
-		}
+	if _, exists := s.Attr("src"); exists {
+		t.replaceInline(i, s)
 	}
 }
 
 // ReplaceInlineCodes finds all child "code" tags and replaces them in place with text content wrapped in "`".
 func (t *Transformer) ReplaceInlineCodes(elm *goquery.Selection) {
-	t.Transform("code", elm, t.ReplaceInlineCode)
+	t.transformInline("code", elm, t.ReplaceInlineCode)
 }
 
 // ReplaceInlineCode replaces the DOM element in place with text content wrapped in "`".
+// If the code contains "`", then it is wrapped in enough "`" to keep the code intact.
 func (t *Transformer) ReplaceInlineCode(i int, s *goquery.Selection) {
-	html, _ := s.Html()
-	s.ReplaceWithHtml(fmt.Sprintf("`%s`", t.textCleaner.CleanText(html)))
+	t.replaceInline(i, s)
 }
 
 // ReplaceItalics finds all child "em" tags and replaces them in place with markdown italics.
 func (t *Transformer) ReplaceItalics(elm *goquery.Selection) {
-	t.Transform("em", elm, t.ReplaceItalic)
+	t.transformInline("em", elm, t.ReplaceItalic)
 }
 
-// ReplaceItalic replaces the DOM element in place with the text content wrapped in "_".
+// ReplaceItalic replaces the DOM element in place with the content wrapped in "_".
 func (t *Transformer) ReplaceItalic(i int, s *goquery.Selection) {
-	s.ReplaceWithHtml(fmt.Sprintf("_%s_", t.textCleaner.CleanText(s.Text())))
+	t.replaceInline(i, s)
 }
 
 // ReplaceBolds finds all child "strong" tags and replaces them in place with markdown bold.
 func (t *Transformer) ReplaceBolds(elm *goquery.Selection) {
-	t.Transform("strong", elm, t.ReplaceBold)
+	t.transformInline("strong", elm, t.ReplaceBold)
 }
 
-// ReplaceBold replaces the DOM element in place with the text content wrapped in "**".
+// ReplaceBold replaces the DOM element in place with the content wrapped in "**".
 func (t *Transformer) ReplaceBold(i int, s *goquery.Selection) {
-	s.ReplaceWithHtml(fmt.Sprintf("**%s**", t.textCleaner.CleanText(s.Text())))
+	t.replaceInline(i, s)
+}
+
+// replaceInline replaces the DOM element in place with its inline markdown.
+// Any formatting within the element is converted along with it.
+func (t *Transformer) replaceInline(i int, s *goquery.Selection) {
+	replaceWithText(s, markdown.RenderInlines(renderInlines(t.renderer, t.nodeToInlines(s.Nodes[0])), markdown.ParagraphContext))
+}
+
+// replaceWithText replaces the DOM element with a text node.
+// Unlike replacing with HTML, the text will not be parsed,
+// so any "<" in the text is kept as text.
+func replaceWithText(s *goquery.Selection, text string) {
+	s.ReplaceWithNodes(&html.Node{Type: html.TextNode, Data: text})
 }
 
 // TextCleaner cleans text content.
 // For example, it trims, removes newlines, and can replace unicode characters with ascii
 type TextCleaner struct {
 	asciiOnly bool
+	replacer  *strings.Replacer
 }
 
 // TextCleanerConf is the configuration for a TextCleaner.
+// Replacements replace each key in the text with its value, such as "→" with "->".
+// They are made before non-ascii characters are removed.
 type TextCleanerConf struct {
-	AsciiOnly bool
+	AsciiOnly    bool
+	Replacements map[string]string
 }
 
 // NewTextCleaner initializes a TextCleaner with the given options.
 // If no options are given, then it will default to only allowing ascii characters.
 func NewTextCleaner(conf *TextCleanerConf) *TextCleaner {
 	asciiOnly := defaultAsciiOnly
+	var replacer *strings.Replacer
 	if conf != nil {
 		asciiOnly = conf.AsciiOnly
+		replacer = newReplacer(conf.Replacements)
+	}
+
+	return &TextCleaner{asciiOnly: asciiOnly, replacer: replacer}
+}
+
+// newReplacer creates a Replacer for the replacements. Longer keys are replaced first,
+// so a key that contains another key takes precedence.
+func newReplacer(replacements map[string]string) *strings.Replacer {
+	if len(replacements) == 0 {
+		return nil
 	}
 
-	return &TextCleaner{asciiOnly: asciiOnly}
+	keys := make([]string, 0, len(replacements))
+	for key := range replacements {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	oldnew := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		oldnew = append(oldnew, key, replacements[key])
+	}
+	return strings.NewReplacer(oldnew...)
 }
 
 // CleanText removes newlines, trims whitespace, and optionally replaces common unicode characters with ascii.
@@ -245,18 +446,7 @@ func (tc *TextCleaner) CleanText(content string) string {
 	lines := strings.Split(content, "\n")
 	trimmed := make([]string, len(lines))
 	for idx, line := range lines {
-		// Replace invisible spaces
-		line = strings.ReplaceAll(line, "\u00a0", " ")
-		line = strings.ReplaceAll(line, "\u00b6", "")
-		// Replace quotes
-		line = strings.ReplaceAll(line, "\u201c", "\"")
-		line = strings.ReplaceAll(line, "\u201d", "\"")
-		line = strings.ReplaceAll(line, "\u2018", "'")
-		line = strings.ReplaceAll(line, "\u2019", "'")
-		if tc.asciiOnly {
-			// Remove any other non-ascii unicode character
-			line = asciiFilter.ReplaceAllLiteralString(line, "")
-		}
+		line = tc.replaceCharacters(line)
 		// Trim any remaining whitespace
 		line = strings.Trim(line, " ")
 
@@ -266,6 +456,33 @@ func (tc *TextCleaner) CleanText(content string) string {
 	return strings.Trim(strings.Join(trimmed, " "), " ")
 }
 
+// CleanInlineText is like CleanText, but is for text that is only part of a block of text,
+// such as the text before a link. Any whitespace is collapsed to a single space,
+// but is not trimmed since it separates the text from the content around it.
+func (tc *TextCleaner) CleanInlineText(content string) string {
+	return whitespacePattern.ReplaceAllLiteralString(tc.replaceCharacters(content), " ")
+}
+
+// replaceCharacters replaces invisible and unicode characters
+func (tc *TextCleaner) replaceCharacters(content string) string {
+	// Replace invisible spaces
+	content = strings.ReplaceAll(content, "\u00a0", " ")
+	content = strings.ReplaceAll(content, "\u00b6", "")
+	// Replace quotes
+	content = strings.ReplaceAll(content, "\u201c", "\"")
+	content = strings.ReplaceAll(content, "\u201d", "\"")
+	content = strings.ReplaceAll(content, "\u2018", "'")
+	content = strings.ReplaceAll(content, "\u2019", "'")
+	if tc.replacer != nil {
+		content = tc.replacer.Replace(content)
+	}
+	if tc.asciiOnly {
+		// Remove any other non-ascii unicode character
+		content = asciiFilter.ReplaceAllLiteralString(content, "")
+	}
+	return content
+}
+
 // PrintUnicodeRunes finds all non-ascii characters in the string and prints out the unicode character point.
 // This is useful for debugging to find unicode characters that need to be handled by the CleanText function.
 func PrintUnicodeRunes(content string) {
@@ -137,6 +137,87 @@ func TestToOrderedList(t *testing.T) {
 	}
 }
 
+func TestToNestedList(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`
+<html>
+	<body>
+		<ul>
+			<li>item 1
+				<ol>
+					<li>step 1</li>
+					<li>step 2
+						<ul>
+							<li>detail</li>
+						</ul>
+					</li>
+				</ol>
+			</li>
+			<li>
+				<p>item 2</p>
+				<p>more about item 2</p>
+				<pre>make build</pre>
+			</li>
+		</ul>
+	</body>
+</html>
+`)
+
+	result := tr.ToList(doc.Find("ul").First()).String()
+	expected := "* item 1\n  1. step 1\n  1. step 2\n     * detail\n* item 2\n\n  more about item 2\n\n  ```\n  make build\n  ```"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestToListWithOnlyNestedList(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`<html><body><ul><li><ul><li>only nested</li></ul></li><li>item 2</li></ul></body></html>`)
+
+	result := tr.ToList(doc.Find("ul").First()).String()
+	expected := "*\n  * only nested\n* item 2"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestToListWithBlockquote(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`<html><body><ul><li>item<blockquote><p>quoted</p><ul><li>nested</li></ul><pre>code</pre></blockquote></li><li>item 2</li></ul></body></html>`)
+
+	result := tr.ToList(doc.Find("ul").First()).String()
+	expected := "* item\n\n  > quoted\n  >\n  > * nested\n  >\n  > ```\n  > code\n  > ```\n* item 2"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestToCodeBlockLanguage(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`
+<html>
+	<body>
+		<pre class="language-go">go</pre>
+		<pre><code class="lang-python">python</code></pre>
+		<pre class="highlight highlight-ruby">ruby</pre>
+		<pre data-lang="sh">sh</pre>
+		<pre class="plain">none</pre>
+	</body>
+</html>
+`)
+
+	expected := []string{"go", "python", "ruby", "sh", ""}
+	doc.Find("pre").Each(func(i int, pre *goquery.Selection) {
+		result := tr.ToCodeBlock(pre).Lang
+		if result != expected[i] {
+			t.Errorf("Expected %s. Got %s", expected[i], result)
+		}
+	})
+}
+
 func TestToTableWithoutHeadAndBody(t *testing.T) {
 	tr := NewTransformer(nil)
 	doc := newTestDoc(`
@@ -184,7 +265,7 @@ func TestToTableWithoutHeaders(t *testing.T) {
 `)
 
 	result := tr.ToTable(doc.Find("table")).String()
-	expected := "| Data 1,1 | Data 1,2 |\n| Data 2,1 | Data 2,2 |"
+	expected := "| Data 1,1 | Data 1,2 |\n| --- | --- |\n| Data 2,1 | Data 2,2 |"
 
 	if result != expected {
 		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
@@ -276,7 +357,7 @@ func TestToTableWithTdAsHeadersInThead(t *testing.T) {
 }
 
 func TestToTableWithMultipleHeaderRows(t *testing.T) {
-	tr := NewTransformer(nil)
+	tr := NewTransformer(&TransformerConf{Diagnostics: NewDiagnostics()})
 	doc := newTestDoc(`
 <html>
 	<body>
@@ -304,11 +385,206 @@ func TestToTableWithMultipleHeaderRows(t *testing.T) {
 	// The second row in thead will be ignored, as markdown only supports one header row
 	expected := "| Column 1 | Column 2 |\n| --- | --- |\n| Data 1 | Data 2 |"
 
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+	if diagnostics := tr.diagnostics.All(); len(diagnostics) != 1 || diagnostics[0].Kind != DiagnosticLossy || !strings.Contains(diagnostics[0].Path, "thead > tr:nth-of-type(2)") {
+		t.Errorf("Expected a lossy diagnostic for the second header row. Got %v", diagnostics)
+	}
+}
+
+func TestToTableWithSpans(t *testing.T) {
+	doc := newTestDoc(`
+<html>
+	<body>
+		<table>
+			<tr>
+				<th colspan="2">Name</th>
+				<th>Size</th>
+			</tr>
+			<tr>
+				<td rowspan="2">Data 1</td>
+				<td>Data 2</td>
+				<td>Data 3</td>
+			</tr>
+			<tr>
+				<td>Data 4</td>
+				<td>Data 5</td>
+			</tr>
+		</table>
+	</body>
+</html>
+`)
+	table := doc.Find("table")
+
+	result := NewTransformer(nil).ToTable(table).String()
+	expected := "| Name |  | Size |\n| --- | --- | --- |\n| Data 1 | Data 2 | Data 3 |\n|  | Data 4 | Data 5 |"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+
+	repeat := SpanFillRepeat
+	result = NewTransformer(&TransformerConf{SpanFill: &repeat}).ToTable(table).String()
+	expected = "| Name | Name | Size |\n| --- | --- | --- |\n| Data 1 | Data 2 | Data 3 |\n| Data 1 | Data 4 | Data 5 |"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestToTableWithAlignment(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`
+<html>
+	<body>
+		<table>
+			<thead>
+				<tr>
+					<th align="left">Column 1</th>
+					<th style="text-align: center;">Column 2</th>
+					<th>Column 3</th>
+				</tr>
+			</thead>
+			<tbody>
+				<tr>
+					<td>Data 1</td>
+					<td>Data 2</td>
+					<td align="right">Data 3</td>
+				</tr>
+			</tbody>
+		</table>
+	</body>
+</html>
+`)
+
+	result := tr.ToTable(doc.Find("table")).String()
+	expected := "| Column 1 | Column 2 | Column 3 |\n| :--- | :---: | ---: |\n| Data 1 | Data 2 | Data 3 |"
+
 	if result != expected {
 		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
 	}
 }
 
+func TestToTableWithInlineContent(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`
+<html>
+	<body>
+		<table>
+			<tr>
+				<th><strong>Column</strong> 1</th>
+				<th>Column 2</th>
+			</tr>
+			<tr>
+				<td>Line 1<br>Line 2</td>
+				<td><p>a | b</p><p><a href="https://example.com">link</a></p></td>
+			</tr>
+			<tr>
+				<td>Short row</td>
+			</tr>
+		</table>
+	</body>
+</html>
+`)
+
+	result := tr.ToTable(doc.Find("table")).String()
+	expected := "| **Column** 1 | Column 2 |\n| --- | --- |\n| Line 1<br>Line 2 | a \\| b<br>[link](https://example.com) |\n| Short row |  |"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestToTableOrHTMLWithBlockContent(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`
+<html>
+	<body>
+		<table class="wrapper">
+			<tr>
+				<td style="color: red;">
+					<ul><li>Item</li></ul>
+				</td>
+				<td><script>alert("hi")</script><table><tr><td>Nested</td></tr></table></td>
+			</tr>
+		</table>
+	</body>
+</html>
+`)
+
+	result := tr.ToTableOrHTML(doc.Find("table").First()).String()
+	expected := "<table>\n<tbody><tr>\n<td>\n<ul><li>Item</li></ul>\n</td>\n<td><table><tbody><tr><td>Nested</td></tr></tbody></table></td>\n</tr>\n</tbody></table>"
+
+	if deepClean(result) != deepClean(expected) {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+	for _, line := range strings.Split(result, "\n") {
+		if strings.TrimSpace(line) == "" {
+			t.Errorf("Expected no blank lines. Got\n%s", result)
+		}
+	}
+}
+
+func TestToRawHTMLWithPre(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc("<html><body><table><tr><td><pre>a\n\n  b &lt; c</pre></td></tr>\n\n<tr><td><pre><code>d\n\ne</code></pre></td></tr></table></body></html>")
+
+	result := tr.ToRawHTML(doc.Find("table")).String()
+	expected := "<table><tbody><tr><td><pre>a&#10;&#10;  b &lt; c</pre></td></tr>\n<tr><td><pre><code>d&#10;&#10;e</code></pre></td></tr></tbody></table>"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestToRawHTMLWithUnsafeElements(t *testing.T) {
+	doc := newTestDoc(`<html><body><div><p>Text</p><iframe src="https://example.com"></iframe><object data="a.swf"><embed src="a.swf"></object><form action="/"><input name="q"><button>Go</button></form><script>alert(1)</script><style>p {}</style></div></body></html>`)
+
+	result := NewTransformer(nil).ToRawHTML(doc.Find("div").First()).String()
+	expected := `<div><p>Text</p></div>`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestToRawHTMLWithUnsafeURLs(t *testing.T) {
+	tests := map[string]struct {
+		content  string
+		expected string
+	}{
+		"javascript link": {
+			content:  `<div><a href="javascript:alert(1)">Link</a></div>`,
+			expected: `<div><a>Link</a></div>`,
+		},
+		"javascript link with whitespace and case": {
+			content:  `<div><a href=" JavaScript:alert(1)">Link</a></div>`,
+			expected: `<div><a>Link</a></div>`,
+		},
+		"data image": {
+			content:  `<div><img src="data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=" alt="Image"/></div>`,
+			expected: `<div><img alt="Image"/></div>`,
+		},
+		"data link": {
+			content:  `<div><a href="data:text/html,<script>alert(1)</script>">Link</a></div>`,
+			expected: `<div><a>Link</a></div>`,
+		},
+		"safe urls": {
+			content:  `<div><a href="https://example.com">Web</a><a href="mailto:me@example.com">Mail</a><a href="../page.html">Page</a><a href="#section">Section</a><img src="http://example.com/image.png"/></div>`,
+			expected: `<div><a href="https://example.com">Web</a><a href="mailto:me@example.com">Mail</a><a href="../page.html">Page</a><a href="#section">Section</a><img src="http://example.com/image.png"/></div>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc := newTestDoc("<html><body>" + test.content + "</body></html>")
+			result := NewTransformer(nil).ToRawHTML(doc.Find("div").First()).String()
+			if result != test.expected {
+				t.Errorf("Expected\n%s\nGot\n%s", test.expected, result)
+			}
+		})
+	}
+}
+
 func TestReplaceAll(t *testing.T) {
 	tr := NewTransformer(nil)
 	doc := newTestDoc(`
@@ -333,6 +609,51 @@ func TestReplaceAll(t *testing.T) {
 	}
 }
 
+func TestReplaceAllNestedFormatting(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`
+<html>
+	<body>
+		<p><a href="mock://example.com"><strong>x</strong></a> and <strong>a <em>b</em></strong></p>
+	</body>
+</html>
+`)
+
+	// The order of replacements must not matter
+	tr.ReplaceItalics(doc.Find("body"))
+	tr.ReplaceAll(doc.Find("body"))
+
+	result := deepClean(doc.Text())
+	expected := "[**x**](mock://example.com) and **a _b_**"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestReplaceAllEscapesText(t *testing.T) {
+	tr := NewTransformer(nil)
+	doc := newTestDoc(`
+<html>
+	<body>
+		<p>2 * 3 = <strong>6</strong>, see [1] and <code>a*b</code> at <a href="mock://example.com/a b">my_page</a></p>
+	</body>
+</html>
+`)
+
+	body := doc.Find("body")
+	tr.ReplaceAll(body)
+	// Converting a child element again must not escape the text twice
+	tr.ReplaceAll(body.Find("p"))
+
+	result := deepClean(body.Text())
+	expected := "2 \\* 3 = **6**, see \\[1\\] and `a*b` at [my_page](mock://example.com/a%20b)"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
 func TestDefaultTextCleaner(t *testing.T) {
 	tc := NewTextCleaner(nil)
 
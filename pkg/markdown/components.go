@@ -5,73 +5,315 @@ import (
 	"strings"
 )
 
-// Header represents a markdown header
+// Header represents a markdown header.
+// The text of the header is Inlines if it has any, or otherwise Content, which is already markdown.
 type Header struct {
 	headerType headerType
 	Content    string
+	Inlines    []Inline
 }
 
 // List represents either an ordered or unorder markdown list
 type List struct {
 	ordinal string
-	Items   []string
+	Items   []ListItem
 }
 
-// Paragraph represents a block of string content
+// ListItem represents a single item of a List.
+// Inlines, or Content if it has no Inlines, is the text of the item, and Blocks
+// are any nested blocks such as child lists, paragraphs, or code blocks,
+// which are rendered indented underneath the item.
+type ListItem struct {
+	Content string
+	Inlines []Inline
+	Blocks  []Block
+}
+
+// Paragraph represents a block of text.
+// The text is Inlines if it has any, or otherwise Content, which is already markdown.
 type Paragraph struct {
 	Content string
+	Inlines []Inline
+}
+
+// Blockquote represents a block of quoted content.
+// The content can be any other block, such as a Doc of paragraphs,
+// lists, code, or other blockquotes.
+type Blockquote struct {
+	Content Block
 }
 
 // Codeblock represents preformatted text such as code.
 // "lang" specifies the programming language of the code.
+// "title" is the name of the code, such as its file name, which is only rendered by some flavors of markdown.
 type CodeBlock struct {
-	Lang string
-	Code string
+	Lang  string
+	Title string
+	Code  string
+}
+
+// Admonition is a callout around its content, such as a note or a warning, with an optional title.
+// A collapsible admonition is hidden until it is expanded, such as a Confluence expand macro.
+type Admonition struct {
+	Kind        string
+	Title       string
+	Collapsible bool
+	Content     *Doc
 }
 
 // HorizontalRule represents a markdown horizontal rule seporator
 type HorizontalRule struct {
 }
 
-// Table represents a markdown table
+// Table represents a markdown table.
+// Alignments sets the alignment of each column, and may be shorter than
+// the number of columns. Rows do not need to be the same length, since
+// every row is filled with empty cells to the width of the widest row.
 type Table struct {
-	Headers []string
-	Rows    [][]string
+	Headers    []TableCell
+	Rows       [][]TableCell
+	Alignments []Alignment
+}
+
+// TableCell is the inline content of a cell of a table
+type TableCell []Inline
+
+// Alignment is the alignment of the content in a column of a table
+type Alignment int
+
+const (
+	// AlignDefault leaves the alignment up to the markdown renderer
+	AlignDefault Alignment = iota
+	// AlignLeft aligns the content to the left of the column
+	AlignLeft
+	// AlignCenter aligns the content to the center of the column
+	AlignCenter
+	// AlignRight aligns the content to the right of the column
+	AlignRight
+)
+
+// RawHTML represents a block of HTML that is rendered as is.
+// It is used for content that cannot be represented in markdown.
+type RawHTML struct {
+	Content string
+}
+
+// NewHeader creates a Header of the level, from 1 to 6.
+// Levels outside of the range are limited to the range.
+func NewHeader(level int, content string) Header {
+	level = min(max(level, 1), 6)
+	return Header{headerType: headerType(strings.Repeat("#", level)), Content: content}
+}
+
+// NewHeaderFromInlines creates a Header of the level, from 1 to 6, with the inline content.
+func NewHeaderFromInlines(level int, inlines []Inline) Header {
+	h := NewHeader(level, "")
+	h.Inlines = inlines
+	return h
 }
 
 // NewUnorderedList creates a new List with the unordered ordinal.
 func NewUnorderedList(items []string) List {
-	return List{ordinal: unorderedChar, Items: items}
+	return NewUnorderedListFromItems(toListItems(items))
 }
 
-// NewOrderedList creates a new List with the unordered ordinal.
+// NewOrderedList creates a new List with the ordered ordinal.
 func NewOrderedList(items []string) List {
+	return NewOrderedListFromItems(toListItems(items))
+}
+
+// NewUnorderedListFromItems creates a new List of ListItems with the unordered ordinal.
+func NewUnorderedListFromItems(items []ListItem) List {
+	return List{ordinal: unorderedChar, Items: items}
+}
+
+// NewOrderedListFromItems creates a new List of ListItems with the ordered ordinal.
+func NewOrderedListFromItems(items []ListItem) List {
 	return List{ordinal: orderedChar, Items: items}
 }
 
+// NewTable creates a Table of cells whose content is already markdown.
+func NewTable(headers []string, rows [][]string) Table {
+	table := Table{Headers: rawTableCells(headers)}
+	for _, row := range rows {
+		table.Rows = append(table.Rows, rawTableCells(row))
+	}
+	return table
+}
+
+// rawTableCells converts the markdown of each cell to a TableCell
+func rawTableCells(cells []string) []TableCell {
+	if cells == nil {
+		return nil
+	}
+	tableCells := make([]TableCell, len(cells))
+	for idx, cell := range cells {
+		if cell != "" {
+			tableCells[idx] = TableCell{RawInline{Content: cell}}
+		}
+	}
+	return tableCells
+}
+
+func toListItems(items []string) []ListItem {
+	listItems := make([]ListItem, len(items))
+	for idx, content := range items {
+		listItems[idx] = ListItem{Content: content}
+	}
+	return listItems
+}
+
+// Level is the level of the header, from 1 to 6
+func (h Header) Level() int {
+	return len(h.headerType)
+}
+
+// WithLevel copies the header with the level changed, from 1 to 6
+func (h Header) WithLevel(level int) Header {
+	header := NewHeader(level, h.Content)
+	header.Inlines = h.Inlines
+	return header
+}
+
+// Text renders the content of the header, without the #'s
+func (h Header) Text() string {
+	if h.Inlines != nil {
+		return RenderInlineText(h.Inlines, HeadingContext)
+	}
+	return h.Content
+}
+
 // String renders the header with the number of #'s according to the type
 func (h Header) String() string {
-	return string(h.headerType) + " " + h.Content
+	return string(h.headerType) + " " + EscapeHeading(h.Text())
+}
+
+// Ordered reports whether the list is an ordered list.
+func (l List) Ordered() bool {
+	return l.ordinal == orderedChar
 }
 
 // String renders the items in the list and prefixes each item with the specificed ordinal
 func (l List) String() string {
 	listItems := make([]string, len(l.Items))
-	for idx, itemContent := range l.Items {
-		listItems[idx] = l.ordinal + " " + strings.Trim(itemContent, " ")
+	for idx, item := range l.Items {
+		listItems[idx] = item.render(l.ordinal)
 	}
 
 	return strings.Join(listItems, "\n")
 }
 
-// String renders the paragraph content into a block of text
-func (p Paragraph) String() string {
+// render renders the item content after the ordinal. Nested blocks are indented
+// to line up with the content so they stay part of the item. Child lists are kept
+// tight against the item, while any other block is separated by a blank line.
+// If the item has no text, then its first block starts on the line after the marker,
+// since a block on the marker line would be read as part of the marker, such as "* * item".
+func (li ListItem) render(ordinal string) string {
+	marker := ordinal + " "
+	indent := strings.Repeat(" ", len(marker))
+
+	text := EscapeBlockStart(strings.Trim(li.Text(), " "))
+	rendered := text
+	for _, block := range li.Blocks {
+		content := block.String()
+		if len(content) == 0 {
+			continue
+		}
+		if len(rendered) > 0 {
+			if _, isList := block.(List); isList {
+				rendered += "\n"
+			} else {
+				rendered += "\n\n"
+			}
+		}
+		rendered += content
+	}
+
+	if len(text) == 0 && len(rendered) > 0 {
+		return ordinal + "\n" + indent + indentLines(rendered, indent)
+	}
+	return marker + indentLines(rendered, indent)
+}
+
+// Text renders the content of the item, without its marker or blocks
+func (li ListItem) Text() string {
+	if li.Inlines != nil {
+		return RenderInlineText(li.Inlines, ListItemContext)
+	}
+	return li.Content
+}
+
+// String renders the item as an item of an unordered list
+func (li ListItem) String() string {
+	return li.render(unorderedChar)
+}
+
+// indentLines prefixes every line after the first with the indent.
+// Empty lines are left empty.
+func indentLines(content string, indent string) string {
+	lines := strings.Split(content, "\n")
+	for idx := 1; idx < len(lines); idx++ {
+		if len(lines[idx]) > 0 {
+			lines[idx] = indent + lines[idx]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Text renders the content of the paragraph
+func (p Paragraph) Text() string {
+	if p.Inlines != nil {
+		return RenderInlineText(p.Inlines, ParagraphContext)
+	}
 	return p.Content
 }
 
-// String wraps the codeblock in ``` with the specified language
+// String renders the paragraph content into a block of text.
+// Any line that would start a different kind of block is escaped.
+func (p Paragraph) String() string {
+	return EscapeBlockStart(p.Text())
+}
+
+// String renders the content and prefixes each line with ">"
+func (b Blockquote) String() string {
+	if b.Content == nil {
+		return ""
+	}
+	content := b.Content.String()
+	if len(content) == 0 {
+		return ""
+	}
+
+	lines := strings.Split(content, "\n")
+	for idx, line := range lines {
+		if len(line) > 0 {
+			lines[idx] = quoteChar + " " + line
+		} else {
+			lines[idx] = quoteChar
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// String wraps the codeblock in ``` with the specified language.
+// If the code contains a run of "`", then the fence is made longer than the run
+// so the code does not close the block early.
 func (cb CodeBlock) String() string {
-	return strings.Join([]string{"```" + cb.Lang, cb.Code, "```"}, "\n")
+	fence := strings.Repeat("`", max(minFenceLength, longestRun(cb.Code, '`')+1))
+	return strings.Join([]string{fence + cb.Lang, cb.Code, fence}, "\n")
+}
+
+// String renders just the content, since standard markdown has no callouts. The title of a
+// collapsible admonition is rendered before the content, since it is the text that shows the content.
+func (a Admonition) String() string {
+	doc := NewDoc(DocConfig{})
+	if a.Collapsible {
+		doc.AddParagraphInlines([]Inline{Text{Content: a.Title}})
+	}
+	if a.Content != nil {
+		doc.AddDoc(a.Content)
+	}
+	return doc.String()
 }
 
 // String renders the horizontal rule as "---"
@@ -80,23 +322,63 @@ func (hr HorizontalRule) String() string {
 }
 
 // String renders the table to to a markdown table.
-// if "headers" are specified, then a row of dividers will be
-// rendered after the headers.
+// A row of dividers is rendered after the headers, which also sets the alignment of each column.
+// If there are no "headers", then the first row is rendered as the headers.
 func (t Table) String() string {
 	var mdTable []string
 
+	// A markdown table must have a header row, so without headers the first row is used
+	if len(t.Headers) == 0 && len(t.Rows) > 0 {
+		t.Headers, t.Rows = t.Rows[0], t.Rows[1:]
+	}
+
+	columns := len(t.Headers)
+	for _, row := range t.Rows {
+		columns = max(columns, len(row))
+	}
+
 	if len(t.Headers) > 0 {
-		dividers := make([]string, len(t.Headers))
-		for i := range t.Headers {
+		dividers := make([]string, columns)
+		for i := range dividers {
 			dividers[i] = "---"
+			if i < len(t.Alignments) {
+				dividers[i] = t.Alignments[i].divider()
+			}
 		}
-		mdTable = append(mdTable, fmt.Sprintf("| %s |", strings.Join(t.Headers, " | ")))
+		mdTable = append(mdTable, renderTableRow(t.Headers, columns))
 		mdTable = append(mdTable, fmt.Sprintf("| %s |", strings.Join(dividers, " | ")))
 	}
 
 	for _, row := range t.Rows {
-		mdTable = append(mdTable, fmt.Sprintf("| %s |", strings.Join(row, " | ")))
+		mdTable = append(mdTable, renderTableRow(row, columns))
 	}
 
 	return strings.Join(mdTable, "\n")
 }
+
+// renderTableRow renders the cells of a row, and adds empty cells up to the number of columns
+func renderTableRow(cells []TableCell, columns int) string {
+	escaped := make([]string, columns)
+	for idx, cell := range cells {
+		escaped[idx] = EscapeTableCell(RenderInlineText(cell, TableCellContext))
+	}
+	return fmt.Sprintf("| %s |", strings.Join(escaped, " | "))
+}
+
+// divider renders the divider between the header and rows of a table for the alignment
+func (a Alignment) divider() string {
+	switch a {
+	case AlignLeft:
+		return ":---"
+	case AlignCenter:
+		return ":---:"
+	case AlignRight:
+		return "---:"
+	}
+	return "---"
+}
+
+// String renders the HTML as is
+func (raw RawHTML) String() string {
+	return raw.Content
+}
@@ -28,6 +28,68 @@ func TestOrderedListToString(t *testing.T) {
 	}
 }
 
+func TestNestedListToString(t *testing.T) {
+	list := NewUnorderedListFromItems([]ListItem{
+		{
+			Content: "item 1",
+			Blocks: []Block{
+				NewOrderedList([]string{"step 1", "step 2"}),
+			},
+		},
+		{
+			Content: "item 2",
+			Blocks: []Block{
+				Paragraph{Content: "paragraph"},
+				CodeBlock{Lang: "sh", Code: "echo 1\n\necho 2"},
+			},
+		},
+	})
+
+	result := list.String()
+	expected := "* item 1\n  1. step 1\n  1. step 2\n* item 2\n\n  paragraph\n\n  ```sh\n  echo 1\n\n  echo 2\n  ```"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestListItemWithoutTextToString(t *testing.T) {
+	list := NewOrderedListFromItems([]ListItem{
+		{Blocks: []Block{NewUnorderedList([]string{"nested"}), Paragraph{Content: "after"}}},
+	})
+
+	result := list.String()
+	expected := "1.\n   * nested\n\n   after"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestDeeplyNestedOrderedListToString(t *testing.T) {
+	list := NewOrderedListFromItems([]ListItem{
+		{
+			Content: "item 1",
+			Blocks: []Block{
+				NewOrderedListFromItems([]ListItem{
+					{
+						Content: "item 1.1",
+						Blocks:  []Block{NewUnorderedList([]string{"item 1.1.1"})},
+					},
+				}),
+			},
+		},
+		{Content: "item 2"},
+	})
+
+	result := list.String()
+	expected := "1. item 1\n   1. item 1.1\n      * item 1.1.1\n1. item 2"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
 func TestH1HeaderToString(t *testing.T) {
 	h := Header{headerType: h1, Content: "Title"}
 
@@ -39,6 +101,23 @@ func TestH1HeaderToString(t *testing.T) {
 	}
 }
 
+func TestNewHeader(t *testing.T) {
+	h := NewHeader(3, "Title")
+
+	result := h.String()
+	expected := "### Title"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+	if h.Level() != 3 {
+		t.Errorf("Expected 3. Got %d", h.Level())
+	}
+	if level := NewHeader(9, "Title").Level(); level != 6 {
+		t.Errorf("Expected 6. Got %d", level)
+	}
+}
+
 func TestH2HeaderToString(t *testing.T) {
 	h := Header{headerType: h2, Content: "Title"}
 
@@ -116,6 +195,78 @@ func TestCodeBlockToString(t *testing.T) {
 	}
 }
 
+func TestBlockquoteToString(t *testing.T) {
+	inner := NewDoc(DocConfig{})
+	inner.AddParagraph("nested quote")
+
+	doc := NewDoc(DocConfig{})
+	doc.AddParagraph("quote")
+	doc.AddUnorderedList([]string{"item 1", "item 2"})
+	doc.AddBlockquote(inner)
+	quote := Blockquote{Content: doc}
+
+	result := quote.String()
+	expected := "> quote\n>\n> * item 1\n> * item 2\n>\n> > nested quote"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestEmptyBlockquoteToString(t *testing.T) {
+	quote := Blockquote{Content: NewDoc(DocConfig{})}
+
+	result := quote.String()
+	expected := ""
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestCodeBlockWithBackticksToString(t *testing.T) {
+	cb := CodeBlock{Lang: "md", Code: "````\ncode\n````"}
+
+	result := cb.String()
+	expected := "`````md\n````\ncode\n````\n`````"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestCodeBlockWithTitleToString(t *testing.T) {
+	cb := CodeBlock{Lang: "sh", Title: "build.sh", Code: "go build"}
+
+	result := cb.String()
+	expected := "```sh\ngo build\n```"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestAdmonitionToString(t *testing.T) {
+	content := NewDoc(DocConfig{})
+	content.AddParagraph("Note content")
+
+	tests := map[string]struct {
+		admonition Admonition
+		expected   string
+	}{
+		"note":        {Admonition{Kind: "note", Title: "Note title", Content: content}, "Note content"},
+		"collapsible": {Admonition{Kind: "note", Title: "Show *more*", Collapsible: true, Content: content}, "Show \\*more\\*\n\nNote content"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := test.admonition.String(); result != test.expected {
+				t.Errorf("Expected %s. Got %s", test.expected, result)
+			}
+		})
+	}
+}
+
 func TestHorizontalRuleToString(t *testing.T) {
 	hr := HorizontalRule{}
 
@@ -133,7 +284,7 @@ func TestTableToStringWithHeaders(t *testing.T) {
 		{"data 1,1", "data 1,2"},
 		{"data 2,1", "data 2,2"},
 	}
-	table := Table{Headers: headers, Rows: rows}
+	table := NewTable(headers, rows)
 
 	result := table.String()
 	expected := "| Column 1 | Column 2 |\n| --- | --- |\n| data 1,1 | data 1,2 |\n| data 2,1 | data 2,2 |"
@@ -142,3 +293,62 @@ func TestTableToStringWithHeaders(t *testing.T) {
 		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
 	}
 }
+
+func TestTableToStringWithoutHeaders(t *testing.T) {
+	table := NewTable(nil, [][]string{{"a", "b"}, {"c", "d"}})
+
+	result := table.String()
+	expected := "| a | b |\n| --- | --- |\n| c | d |"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestTableToStringWithAlignments(t *testing.T) {
+	table := NewTable([]string{"Left", "Center", "Right", "Default"}, [][]string{{"1", "2", "3", "4"}})
+	table.Alignments = []Alignment{AlignLeft, AlignCenter, AlignRight}
+
+	result := table.String()
+	expected := "| Left | Center | Right | Default |\n| :--- | :---: | ---: | --- |\n| 1 | 2 | 3 | 4 |"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestTableToStringWithUnevenRows(t *testing.T) {
+	table := NewTable([]string{"Column 1"}, [][]string{
+		{"data 1,1", "data 1,2"},
+		{"data 2,1", "data 2,2", "data 2,3"},
+	})
+
+	result := table.String()
+	expected := "| Column 1 |  |  |\n| --- | --- | --- |\n| data 1,1 | data 1,2 |  |\n| data 2,1 | data 2,2 | data 2,3 |"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestTableToStringEscapesPipes(t *testing.T) {
+	table := NewTable([]string{"a | b"}, [][]string{{`c \| d`}})
+
+	result := table.String()
+	expected := "| a \\| b |\n| --- |\n| c \\| d |"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestRawHTMLToString(t *testing.T) {
+	raw := RawHTML{Content: "<table><tr><td>data</td></tr></table>"}
+
+	result := raw.String()
+	expected := "<table><tr><td>data</td></tr></table>"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
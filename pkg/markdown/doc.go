@@ -2,7 +2,6 @@
 package markdown
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/david-mk-lawrence/htmltomd/pkg/util"
@@ -20,6 +19,9 @@ const (
 
 	unorderedChar = "*"
 	orderedChar   = "1."
+	quoteChar     = ">"
+
+	minFenceLength = 3
 
 	defaultSeparator = "\n\n"
 )
@@ -47,25 +49,45 @@ var (
 )
 
 // Doc represents a markdown document.
-// It holds a list of fmt.Stringer which represent
+// It holds a list of Blocks which represent
 // each block of text that will be rendered
 type Doc struct {
-	content       []fmt.Stringer
+	content       []Block
+	positions     []Position
 	title         *string
+	frontMatter   *FrontMatter
+	titleHeading  *bool
 	separator     *string
 	reduceHeaders *bool
 }
 
+// Position is the place in the source that a block was converted from.
+// Line is 0 if the line is not known.
+type Position struct {
+	Line int    `json:"line,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
 // DocConfig contains parameters that are used to intialize a new Doc.
+// TitleHeading controls whether the title is rendered as a header,
+// which may not be wanted if the title is already in the FrontMatter.
 type DocConfig struct {
 	Title         *string
+	FrontMatter   *FrontMatter
+	TitleHeading  *bool
 	ReduceHeaders *bool
 	Separator     *string
 }
 
 // NewDoc intializes a new Doc.
 func NewDoc(conf DocConfig) *Doc {
-	doc := &Doc{title: conf.Title}
+	doc := &Doc{title: conf.Title, frontMatter: conf.FrontMatter}
+
+	if conf.TitleHeading == nil {
+		doc.titleHeading = util.Bool(true)
+	} else {
+		doc.titleHeading = conf.TitleHeading
+	}
 
 	if conf.ReduceHeaders == nil {
 		doc.reduceHeaders = util.Bool(true)
@@ -85,13 +107,15 @@ func NewDoc(conf DocConfig) *Doc {
 func (d *Doc) GetConfig() DocConfig {
 	return DocConfig{
 		Title:         d.title,
+		FrontMatter:   d.frontMatter,
+		TitleHeading:  d.titleHeading,
 		Separator:     d.separator,
 		ReduceHeaders: d.reduceHeaders,
 	}
 }
 
 // GetRenderConfig retrieves the config without document content config
-// like "title". This is convenient when needing to preserve config for
+// like "title" or "front matter". This is convenient when needing to preserve config for
 // rendering only when creating a child document from a parent. Otherwise,
 // if all the config is copied to the child, then "title" would be rendered
 // twice.
@@ -103,8 +127,43 @@ func (d *Doc) GetRenderConfig() DocConfig {
 }
 
 // AddContent adds a block to the document.
-func (d *Doc) AddContent(content fmt.Stringer) {
+func (d *Doc) AddContent(content Block) {
 	d.content = append(d.content, content)
+	d.positions = append(d.positions, Position{})
+}
+
+// Blocks are the blocks that have been added to the document
+func (d *Doc) Blocks() []Block {
+	return d.content
+}
+
+// SetBlocks replaces the blocks of the document. The blocks have no positions.
+func (d *Doc) SetBlocks(blocks []Block) {
+	d.content = blocks
+	d.positions = make([]Position, len(blocks))
+}
+
+// Position gets the position in the source of the block at the index
+func (d *Doc) Position(idx int) Position {
+	if idx < 0 || idx >= len(d.positions) {
+		return Position{}
+	}
+	return d.positions[idx]
+}
+
+// SetPositions sets the position of the blocks from the index to the end of the document
+// that do not have a position yet, such as the blocks converted from a single element.
+func (d *Doc) SetPositions(from int, pos Position) {
+	for idx := max(from, 0); idx < len(d.positions); idx++ {
+		if d.positions[idx] == (Position{}) {
+			d.positions[idx] = pos
+		}
+	}
+}
+
+// Len is the number of blocks that have been added to the document.
+func (d *Doc) Len() int {
+	return len(d.content)
 }
 
 // AddDoc adds another markdown document as a block to this document.
@@ -118,20 +177,38 @@ func (d *Doc) AddDoc(subdoc *Doc) {
 // will be reduced one level.
 func (d *Doc) AddHeader(headerTag string, content string) {
 	if len(content) > 0 {
-		var hType headerType
-		if d.reduceHeaders != nil && *d.reduceHeaders {
-			hType = reducedHeaderMap[headerTag]
-		} else {
-			hType = headerMap[headerTag]
-		}
-
 		d.AddContent(Header{
-			headerType: hType,
+			headerType: d.headerType(headerTag),
 			Content:    content,
 		})
 	}
 }
 
+// HeaderLevel is the level of the header that the headerTag, such as "h2", is rendered as.
+// If the doc has reduceHeaders set, then the level is one more than the tag.
+func (d *Doc) HeaderLevel(headerTag string) int {
+	return len(d.headerType(headerTag))
+}
+
+func (d *Doc) headerType(headerTag string) headerType {
+	if d.reduceHeaders != nil && *d.reduceHeaders {
+		return reducedHeaderMap[headerTag]
+	}
+	return headerMap[headerTag]
+}
+
+// AddHeaderInlines adds a section header with the inline content to the document.
+// If the content is empty, it will not be added to the document.
+// If the doc has reduceHeaders set, then the headerTag will be reduced one level.
+func (d *Doc) AddHeaderInlines(headerTag string, inlines []Inline) {
+	if len(RenderInlineText(inlines, HeadingContext)) > 0 {
+		d.AddContent(Header{
+			headerType: d.headerType(headerTag),
+			Inlines:    inlines,
+		})
+	}
+}
+
 // AddUnorderedList adds a List to the document.
 // It sets the unordered prefix ordinal.
 func (d *Doc) AddUnorderedList(items []string) {
@@ -153,6 +230,19 @@ func (d *Doc) AddParagraph(content string) {
 	}
 }
 
+// AddParagraphInlines adds a block of the inline content to the document.
+// If the content is empty, it will not be added to the document.
+func (d *Doc) AddParagraphInlines(inlines []Inline) {
+	if len(RenderInlineText(inlines, ParagraphContext)) > 0 {
+		d.AddContent(Paragraph{Inlines: inlines})
+	}
+}
+
+// AddBlockquote adds another markdown document as a quoted block to this document.
+func (d *Doc) AddBlockquote(quote *Doc) {
+	d.AddContent(Blockquote{Content: quote})
+}
+
 // AddCodeBlock adds a block of code to the document.
 func (d *Doc) AddCodeBlock(lang string, code string) {
 	d.AddContent(CodeBlock{
@@ -166,9 +256,63 @@ func (d *Doc) AddHorizontalRule() {
 	d.AddContent(HorizontalRule{})
 }
 
-// AddTable adds a table to the document.
+// AddTable adds a table to the document. The content of each cell is already markdown.
 func (d *Doc) AddTable(headers []string, rows [][]string) {
-	d.AddContent(Table{Headers: headers, Rows: rows})
+	d.AddContent(NewTable(headers, rows))
+}
+
+// AddRawHTML adds a block of HTML to the document, which is rendered as is.
+func (d *Doc) AddRawHTML(content string) {
+	d.AddContent(RawHTML{Content: content})
+}
+
+// Clone copies the document, along with the documents within its blocks,
+// so that the copy can be rewritten without changing the document.
+func (d *Doc) Clone() *Doc {
+	clone := *d
+	clone.content = cloneBlocks(d.content)
+	clone.positions = append([]Position(nil), d.positions...)
+	return &clone
+}
+
+// cloneBlocks copies the blocks, along with the documents within them
+func cloneBlocks(blocks []Block) []Block {
+	if blocks == nil {
+		return nil
+	}
+	clones := make([]Block, len(blocks))
+	for idx, block := range blocks {
+		clones[idx] = cloneBlock(block)
+	}
+	return clones
+}
+
+// cloneBlock copies the block, along with the documents within it.
+// Blocks are values, so only the blocks that can contain documents are copied.
+func cloneBlock(block Block) Block {
+	switch b := block.(type) {
+	case *Doc:
+		return b.Clone()
+	case Blockquote:
+		if b.Content != nil {
+			b.Content = cloneBlock(b.Content)
+		}
+		return b
+	case Admonition:
+		if b.Content != nil {
+			b.Content = b.Content.Clone()
+		}
+		return b
+	case List:
+		items := make([]ListItem, len(b.Items))
+		for idx, item := range b.Items {
+			item.Blocks = cloneBlocks(item.Blocks)
+			items[idx] = item
+		}
+		b.Items = items
+		return b
+	}
+	return block
 }
 
 // Content renders just the content of the document.
@@ -185,23 +329,43 @@ func (d Doc) Content() string {
 	return strings.Join(contentLines, d.getSeparator())
 }
 
-// Title renders just the title of the document
+// Title renders just the title of the document.
+// The title is plain text, so it is escaped as a header.
+// Nothing is rendered if the title is empty or the title heading is disabled.
 func (d *Doc) Title() string {
 	title := ""
-	if d.title != nil {
-		title = string(h1) + " " + *d.title
+	if d.title != nil && *d.title != "" && (d.titleHeading == nil || *d.titleHeading) {
+		title = string(h1) + " " + Escape(*d.title, HeadingContext)
 	}
 	return title
 }
 
-// String renders the title with the content.
+// FrontMatter gets the front matter of the document.
+// It is nil if the document has no front matter.
+func (d *Doc) FrontMatter() *FrontMatter {
+	return d.frontMatter
+}
+
+// String renders the front matter and the title with the content.
 func (d *Doc) String() string {
-	title := d.Title()
-	if title != "" {
-		title += d.getSeparator()
+	return d.joinBlocks(d.frontMatter.String(), d.Body())
+}
+
+// Body renders the title with the content, without the front matter.
+func (d *Doc) Body() string {
+	return d.joinBlocks(d.Title(), d.Content())
+}
+
+// joinBlocks joins the rendered blocks that are not empty with the separator
+func (d *Doc) joinBlocks(rendered ...string) string {
+	var blocks []string
+	for _, block := range rendered {
+		if block != "" {
+			blocks = append(blocks, block)
+		}
 	}
 
-	return title + d.Content()
+	return strings.Join(blocks, d.getSeparator())
 }
 
 func (d *Doc) getSeparator() string {
@@ -6,13 +6,6 @@ import (
 	"github.com/david-mk-lawrence/htmltomd/pkg/util"
 )
 
-type testContent struct {
-}
-
-func (tc testContent) String() string {
-	return "test"
-}
-
 func TestGetConfigWithDefaults(t *testing.T) {
 	doc := NewDoc(DocConfig{})
 
@@ -63,7 +56,7 @@ func TestGetRenderConfig(t *testing.T) {
 
 func TestAddDoc(t *testing.T) {
 	subdoc := NewDoc(DocConfig{})
-	subdoc.AddContent(testContent{})
+	subdoc.AddContent(RawBlock{Content: "test"})
 
 	doc := NewDoc(DocConfig{})
 	doc.AddDoc(subdoc)
@@ -76,6 +69,32 @@ func TestAddDoc(t *testing.T) {
 	}
 }
 
+func TestClone(t *testing.T) {
+	quote := NewDoc(DocConfig{})
+	quote.AddParagraph("quoted")
+	doc := NewDoc(DocConfig{})
+	doc.AddParagraph("text")
+	doc.AddBlockquote(quote)
+
+	clone := doc.Clone()
+	Rewrite(clone, func(node Node) Node {
+		if p, isParagraph := node.(Paragraph); isParagraph {
+			p.Content = "changed"
+			return p
+		}
+		return node
+	})
+
+	expected := "text\n\n> quoted"
+	if result := doc.String(); result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+	expected = "changed\n\n> changed"
+	if result := clone.String(); result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
 func TestAddHeaderWithReducedHeaders(t *testing.T) {
 	doc := NewDoc(DocConfig{})
 	doc.AddHeader("h1", "Header")
@@ -149,6 +168,21 @@ func TestAddEmptyParagraph(t *testing.T) {
 	}
 }
 
+func TestAddBlockquote(t *testing.T) {
+	quote := NewDoc(DocConfig{})
+	quote.AddParagraph("Quote")
+
+	doc := NewDoc(DocConfig{})
+	doc.AddBlockquote(quote)
+
+	result := doc.String()
+	expected := "> Quote"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
 func TestAddCodeBlock(t *testing.T) {
 	doc := NewDoc(DocConfig{})
 	doc.AddCodeBlock("go", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"Hello World\")\n}")
@@ -226,3 +260,33 @@ func TestContentWithTitle(t *testing.T) {
 		t.Errorf("Expected %s. Got %s", expected, result)
 	}
 }
+
+func TestContentWithFrontMatter(t *testing.T) {
+	title := "Test Doc"
+	fm := NewFrontMatter(FrontMatterYAML)
+	fm.Set("title", title)
+	doc := NewDoc(DocConfig{Title: &title, FrontMatter: fm})
+	doc.AddParagraph("Paragraph")
+
+	result := doc.String()
+	expected := "---\ntitle: \"Test Doc\"\n---\n\n# Test Doc\n\nParagraph"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestContentWithoutTitleHeading(t *testing.T) {
+	title := "Test Doc"
+	fm := NewFrontMatter(FrontMatterTOML)
+	fm.Set("title", title)
+	doc := NewDoc(DocConfig{Title: &title, FrontMatter: fm, TitleHeading: util.Bool(false)})
+	doc.AddParagraph("Paragraph")
+
+	result := doc.String()
+	expected := "+++\ntitle = \"Test Doc\"\n+++\n\nParagraph"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
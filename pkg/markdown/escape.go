@@ -0,0 +1,170 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// EscapeContext is the place in a markdown document where text will be rendered.
+// Which characters need to be escaped depends on where the text is placed.
+type EscapeContext int
+
+const (
+	// ParagraphContext is text in a block of text
+	ParagraphContext EscapeContext = iota
+	// TableCellContext is text in a cell of a table
+	TableCellContext
+	// LinkTextContext is the text of a link or the alt text of an image
+	LinkTextContext
+	// LinkURLContext is the destination of a link or image
+	LinkURLContext
+	// HeadingContext is the text of a header
+	HeadingContext
+	// ListItemContext is the text of an item in a list
+	ListItemContext
+)
+
+const asciiPunctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+var (
+	blockStartPattern   = regexp.MustCompile(`^( {0,3})(#{1,6}(?:[ \t]|$)|[-+*](?:[ \t]|$)|>|=+[ \t]*$|-+[ \t]*$)`)
+	orderedStartPattern = regexp.MustCompile(`^( {0,3}\d{1,9})([.)](?:[ \t]|$))`)
+	headingEndPattern   = regexp.MustCompile(`(^|[ \t])(#+)([ \t]*)$`)
+	entityPattern       = regexp.MustCompile(`^&#?[[:alnum:]]+;`)
+
+	urlReplacer = strings.NewReplacer(
+		" ", "%20",
+		"<", "%3C",
+		">", "%3E",
+		"(", `\(`,
+		")", `\)`,
+	)
+)
+
+// Escape escapes the text so that it renders as the same visible text
+// when it is placed in the given context of a markdown document.
+func Escape(text string, ctx EscapeContext) string {
+	switch ctx {
+	case LinkURLContext:
+		return urlReplacer.Replace(text)
+	case TableCellContext:
+		return EscapeTableCell(EscapeInline(text))
+	case HeadingContext:
+		return EscapeHeading(EscapeInline(text))
+	case ParagraphContext, ListItemContext:
+		return EscapeBlockStart(EscapeInline(text))
+	default:
+		return EscapeInline(text)
+	}
+}
+
+// EscapeInline escapes characters that would otherwise start inline formatting
+// such as emphasis, code, links, html tags, and entities.
+// It does not escape characters that are only significant at the start of a line.
+func EscapeInline(text string) string {
+	runes := []rune(text)
+	var escaped strings.Builder
+	for idx, r := range runes {
+		var next rune
+		if idx+1 < len(runes) {
+			next = runes[idx+1]
+		}
+
+		switch r {
+		case '*', '`', '[', ']', '~':
+			escaped.WriteRune('\\')
+		case '\\':
+			// A backslash is only special before punctuation, but it may be followed
+			// by other content once rendered, so always escape a trailing backslash.
+			if next == 0 || strings.ContainsRune(asciiPunctuation, next) {
+				escaped.WriteRune('\\')
+			}
+		case '_':
+			// Underscores within a word never start emphasis
+			if idx == 0 || next == 0 || !isWordRune(runes[idx-1]) || !isWordRune(next) {
+				escaped.WriteRune('\\')
+			}
+		case '<':
+			if next == 0 || next == '/' || next == '!' || next == '?' || unicode.IsLetter(next) {
+				escaped.WriteRune('\\')
+			}
+		case '&':
+			if entityPattern.MatchString(string(runes[idx:])) {
+				escaped.WriteRune('\\')
+			}
+		}
+		escaped.WriteRune(r)
+	}
+
+	return escaped.String()
+}
+
+// EscapeBlockStart escapes characters at the start of each line that would
+// otherwise turn the line into a header, list, blockquote, or horizontal rule.
+func EscapeBlockStart(text string) string {
+	lines := strings.Split(text, "\n")
+	for idx, line := range lines {
+		if orderedStartPattern.MatchString(line) {
+			lines[idx] = orderedStartPattern.ReplaceAllString(line, `$1\$2`)
+		} else if blockStartPattern.MatchString(line) {
+			lines[idx] = blockStartPattern.ReplaceAllString(line, `$1\$2`)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// EscapeHeading escapes a trailing sequence of "#" characters which would
+// otherwise be treated as the closing sequence of the header.
+func EscapeHeading(text string) string {
+	return headingEndPattern.ReplaceAllString(text, `$1\$2$3`)
+}
+
+// EscapeTableCell escapes any unescaped "|" so it does not split the cell,
+// and replaces newlines since a cell must be on a single line.
+func EscapeTableCell(text string) string {
+	var escaped strings.Builder
+	backslashes := 0
+	for _, r := range strings.ReplaceAll(text, "\n", " ") {
+		if r == '|' && backslashes%2 == 0 {
+			escaped.WriteRune('\\')
+		}
+		if r == '\\' {
+			backslashes++
+		} else {
+			backslashes = 0
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// InlineCode wraps the code in enough backticks that any backticks
+// in the code are rendered as part of the code.
+func InlineCode(code string) string {
+	fence := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// longestRun finds the length of the longest consecutive run of the character in the content.
+func longestRun(content string, char rune) int {
+	longest, current := 0, 0
+	for _, r := range content {
+		if r == char {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
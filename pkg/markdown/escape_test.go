@@ -0,0 +1,84 @@
+package markdown
+
+import "testing"
+
+func TestEscapeParagraph(t *testing.T) {
+	cases := map[string]string{
+		"a *starred* word":    `a \*starred\* word`,
+		"snake_case_name":     "snake_case_name",
+		"_leading underscore": `\_leading underscore`,
+		"use `code` here":     "use \\`code\\` here",
+		"[not a link](url)":   `\[not a link\](url)`,
+		"# not a header":      `\# not a header`,
+		"1. not a list":       `1\. not a list`,
+		"- not a list":        `\- not a list`,
+		"> not a quote":       `\> not a quote`,
+		"<div> is text":       `\<div> is text`,
+		"1 < 2":               "1 < 2",
+		"&amp; is text":       `\&amp; is text`,
+		"C:\\path\\":          `C:\path\\`,
+		"a\n---":              "a\n\\---",
+		"-5 degrees":          "-5 degrees",
+	}
+
+	for text, expected := range cases {
+		result := Escape(text, ParagraphContext)
+		if result != expected {
+			t.Errorf("Expected %s. Got %s", expected, result)
+		}
+	}
+}
+
+func TestEscapeTableCell(t *testing.T) {
+	result := Escape("a | b \\| c", TableCellContext)
+	expected := `a \| b \\\| c`
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestEscapeHeading(t *testing.T) {
+	result := Escape("Learning C #", HeadingContext)
+	expected := `Learning C \#`
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestEscapeLinkURL(t *testing.T) {
+	result := Escape("https://example.com/a page_(1)", LinkURLContext)
+	expected := `https://example.com/a%20page_\(1\)`
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestEscapeIsStableForComponents(t *testing.T) {
+	escaped := Escape("1. a | b #", ParagraphContext)
+
+	if result := EscapeBlockStart(escaped); result != escaped {
+		t.Errorf("Expected %s. Got %s", escaped, result)
+	}
+	if result := EscapeHeading(Escape("C #", HeadingContext)); result != `C \#` {
+		t.Errorf("Expected %s. Got %s", `C \#`, result)
+	}
+}
+
+func TestInlineCode(t *testing.T) {
+	cases := map[string]string{
+		"code":       "`code`",
+		"a ` b":      "``a ` b``",
+		"`start":     "`` `start ``",
+		"a `` b ` c": "```a `` b ` c```",
+	}
+
+	for code, expected := range cases {
+		result := InlineCode(code)
+		if result != expected {
+			t.Errorf("Expected %s. Got %s", expected, result)
+		}
+	}
+}
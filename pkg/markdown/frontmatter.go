@@ -0,0 +1,228 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FrontMatterFormat is the format that front matter is serialized as
+type FrontMatterFormat string
+
+const (
+	// FrontMatterNone does not render front matter
+	FrontMatterNone FrontMatterFormat = ""
+	// FrontMatterYAML renders front matter as YAML between "---" lines
+	FrontMatterYAML FrontMatterFormat = "yaml"
+	// FrontMatterTOML renders front matter as TOML between "+++" lines
+	FrontMatterTOML FrontMatterFormat = "toml"
+	// FrontMatterJSON renders front matter as a JSON object
+	FrontMatterJSON FrontMatterFormat = "json"
+)
+
+var (
+	yamlPlainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	tomlBareKey  = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// ParseFrontMatterFormat converts the name of a format to a FrontMatterFormat.
+// "none" and an empty name are both FrontMatterNone.
+func ParseFrontMatterFormat(name string) (FrontMatterFormat, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return FrontMatterNone, nil
+	case "yaml", "yml":
+		return FrontMatterYAML, nil
+	case "toml":
+		return FrontMatterTOML, nil
+	case "json":
+		return FrontMatterJSON, nil
+	}
+	return FrontMatterNone, fmt.Errorf("unknown front matter format %q. Can be 'yaml', 'toml', 'json', or 'none'", name)
+}
+
+// FrontMatter holds metadata about a document which is rendered
+// before the content of the document. Keys are rendered in the
+// order they were first set.
+//
+// Values may be strings, bools, numbers, time.Time, or []string.
+// Any other type is rendered as a string.
+type FrontMatter struct {
+	Format FrontMatterFormat
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewFrontMatter creates an empty FrontMatter that is rendered with the given format.
+func NewFrontMatter(format FrontMatterFormat) *FrontMatter {
+	return &FrontMatter{Format: format, values: map[string]interface{}{}}
+}
+
+// Set sets the value of the key.
+// If the key is already set, the value is replaced but the key keeps its position.
+func (fm *FrontMatter) Set(key string, value interface{}) {
+	if fm.values == nil {
+		fm.values = map[string]interface{}{}
+	}
+	if _, exists := fm.values[key]; !exists {
+		fm.keys = append(fm.keys, key)
+	}
+	fm.values[key] = value
+}
+
+// Get gets the value of the key, and whether the key is set.
+func (fm *FrontMatter) Get(key string) (interface{}, bool) {
+	value, exists := fm.values[key]
+	return value, exists
+}
+
+// Delete removes the key.
+func (fm *FrontMatter) Delete(key string) {
+	if _, exists := fm.values[key]; !exists {
+		return
+	}
+	delete(fm.values, key)
+	for idx, k := range fm.keys {
+		if k == key {
+			fm.keys = append(fm.keys[:idx], fm.keys[idx+1:]...)
+			break
+		}
+	}
+}
+
+// Keys gets the keys in the order they were set.
+func (fm *FrontMatter) Keys() []string {
+	return append([]string(nil), fm.keys...)
+}
+
+// Len is the number of keys that are set.
+func (fm *FrontMatter) Len() int {
+	return len(fm.keys)
+}
+
+// Merge sets all the keys from the other front matter.
+// Values from the other front matter replace existing values.
+func (fm *FrontMatter) Merge(other *FrontMatter) {
+	if other == nil {
+		return
+	}
+	for _, key := range other.keys {
+		fm.Set(key, other.values[key])
+	}
+}
+
+// String renders the front matter in its format.
+// If there are no keys, or the format is FrontMatterNone, then nothing is rendered.
+func (fm *FrontMatter) String() string {
+	if fm == nil || len(fm.keys) == 0 {
+		return ""
+	}
+
+	switch fm.Format {
+	case FrontMatterYAML:
+		return fm.yaml()
+	case FrontMatterTOML:
+		return fm.toml()
+	case FrontMatterJSON:
+		return fm.json()
+	}
+	return ""
+}
+
+func (fm *FrontMatter) yaml() string {
+	lines := []string{"---"}
+	for _, key := range fm.keys {
+		name := key
+		if !yamlPlainKey.MatchString(key) {
+			name = quote(key)
+		}
+
+		switch value := fm.values[key].(type) {
+		case []string:
+			if len(value) == 0 {
+				lines = append(lines, name+": []")
+				continue
+			}
+			lines = append(lines, name+":")
+			for _, item := range value {
+				lines = append(lines, "  - "+quote(item))
+			}
+		default:
+			lines = append(lines, name+": "+renderScalar(value, false))
+		}
+	}
+	lines = append(lines, "---")
+	return strings.Join(lines, "\n")
+}
+
+func (fm *FrontMatter) toml() string {
+	lines := []string{"+++"}
+	for _, key := range fm.keys {
+		name := key
+		if !tomlBareKey.MatchString(key) {
+			name = quote(key)
+		}
+
+		switch value := fm.values[key].(type) {
+		case []string:
+			items := make([]string, len(value))
+			for idx, item := range value {
+				items[idx] = quote(item)
+			}
+			lines = append(lines, name+" = ["+strings.Join(items, ", ")+"]")
+		default:
+			lines = append(lines, name+" = "+renderScalar(value, false))
+		}
+	}
+	lines = append(lines, "+++")
+	return strings.Join(lines, "\n")
+}
+
+func (fm *FrontMatter) json() string {
+	lines := make([]string, len(fm.keys))
+	for idx, key := range fm.keys {
+		var rendered string
+		switch value := fm.values[key].(type) {
+		case []string:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = quote(item)
+			}
+			rendered = "[" + strings.Join(items, ", ") + "]"
+		default:
+			rendered = renderScalar(value, true)
+		}
+		lines[idx] = "  " + quote(key) + ": " + rendered
+	}
+	return "{\n" + strings.Join(lines, ",\n") + "\n}"
+}
+
+// renderScalar renders a single value. Dates are only quoted for JSON,
+// since YAML and TOML both support dates without quotes.
+func renderScalar(value interface{}, quoteDates bool) string {
+	switch v := value.(type) {
+	case string:
+		return quote(v)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case time.Time:
+		date := v.Format(time.RFC3339)
+		if quoteDates {
+			return quote(date)
+		}
+		return date
+	}
+	return quote(fmt.Sprint(value))
+}
+
+// quote renders the string as a double quoted string.
+// A JSON string is also a valid double quoted string in both YAML and TOML.
+func quote(value string) string {
+	var quoted strings.Builder
+	encoder := json.NewEncoder(&quoted)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	return strings.TrimSuffix(quoted.String(), "\n")
+}
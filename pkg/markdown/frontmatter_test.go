@@ -0,0 +1,118 @@
+package markdown
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestFrontMatter(format FrontMatterFormat) *FrontMatter {
+	fm := NewFrontMatter(format)
+	fm.Set("title", "Test \"Doc\"")
+	fm.Set("date", time.Date(2020, 3, 3, 0, 0, 0, 0, time.UTC))
+	fm.Set("draft", false)
+	fm.Set("tags", []string{"a", "b"})
+	fm.Set("sidebar position", 2)
+	return fm
+}
+
+func TestYAMLFrontMatterToString(t *testing.T) {
+	result := newTestFrontMatter(FrontMatterYAML).String()
+	expected := `---
+title: "Test \"Doc\""
+date: 2020-03-03T00:00:00Z
+draft: false
+tags:
+  - "a"
+  - "b"
+"sidebar position": 2
+---`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestTOMLFrontMatterToString(t *testing.T) {
+	result := newTestFrontMatter(FrontMatterTOML).String()
+	expected := `+++
+title = "Test \"Doc\""
+date = 2020-03-03T00:00:00Z
+draft = false
+tags = ["a", "b"]
+"sidebar position" = 2
++++`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestJSONFrontMatterToString(t *testing.T) {
+	result := newTestFrontMatter(FrontMatterJSON).String()
+	expected := `{
+  "title": "Test \"Doc\"",
+  "date": "2020-03-03T00:00:00Z",
+  "draft": false,
+  "tags": ["a", "b"],
+  "sidebar position": 2
+}`
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestFrontMatterKeepsKeyOrder(t *testing.T) {
+	fm := NewFrontMatter(FrontMatterYAML)
+	fm.Set("b", "1")
+	fm.Set("a", "2")
+	fm.Set("b", "3")
+	fm.Delete("c")
+
+	other := NewFrontMatter(FrontMatterNone)
+	other.Set("c", "4")
+	other.Set("a", "5")
+	fm.Merge(other)
+
+	result := fm.String()
+	expected := "---\nb: \"3\"\na: \"5\"\nc: \"4\"\n---"
+
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestEmptyFrontMatterToString(t *testing.T) {
+	fm := NewFrontMatter(FrontMatterNone)
+	fm.Set("title", "Test Doc")
+
+	if result := fm.String(); result != "" {
+		t.Errorf("Expected no front matter. Got %s", result)
+	}
+	if result := NewFrontMatter(FrontMatterYAML).String(); result != "" {
+		t.Errorf("Expected no front matter. Got %s", result)
+	}
+}
+
+func TestParseFrontMatterFormat(t *testing.T) {
+	cases := map[string]FrontMatterFormat{
+		"":     FrontMatterNone,
+		"none": FrontMatterNone,
+		"YAML": FrontMatterYAML,
+		"toml": FrontMatterTOML,
+		"json": FrontMatterJSON,
+	}
+	for name, expected := range cases {
+		result, err := ParseFrontMatterFormat(name)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+		if result != expected {
+			t.Errorf("Expected %s. Got %s", expected, result)
+		}
+	}
+
+	if _, err := ParseFrontMatterFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,265 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Inline is content within a block of text, such as text, emphasis, or links.
+// Inline content can be nested, for example a link containing bold text.
+type Inline interface {
+	Node
+	renderInline(ctx EscapeContext) string
+}
+
+// Text is plain text. Markdown characters in the text are escaped when rendered.
+type Text struct {
+	Content string
+}
+
+// Emphasis is text rendered in italics
+type Emphasis struct {
+	Children []Inline
+}
+
+// Strong is text rendered in bold
+type Strong struct {
+	Children []Inline
+}
+
+// Strikethrough is text rendered with a line through it
+type Strikethrough struct {
+	Children []Inline
+}
+
+// Code is inline code. The content is rendered as is.
+type Code struct {
+	Content string
+}
+
+// Link is a link to the URL, with the children as the text of the link
+type Link struct {
+	URL      string
+	Title    string
+	Children []Inline
+}
+
+// Image is an image with the alt text
+type Image struct {
+	Src   string
+	Alt   string
+	Title string
+}
+
+// LineBreak is a hard line break within a block of text
+type LineBreak struct {
+}
+
+// RawInline is content that is already markdown, or inline HTML.
+// It is rendered as is.
+type RawInline struct {
+	Content string
+}
+
+// RenderInlines renders the inline content for the context it is placed in.
+// Whitespace between the inline content is collapsed to a single space,
+// but whitespace at the start and end is kept.
+func RenderInlines(inlines []Inline, ctx EscapeContext) string {
+	rendered := make([]string, len(inlines))
+	for idx, inline := range inlines {
+		rendered[idx] = inline.renderInline(ctx)
+	}
+
+	// "_" does not create emphasis within a word, so "*" is used if the emphasis touches a word
+	for idx, inline := range inlines {
+		if em, isEmphasis := inline.(Emphasis); isEmphasis && touchesWord(rendered, idx) {
+			rendered[idx] = wrapInline(RenderInlines(em.Children, ctx), "*", "*")
+		}
+	}
+
+	var content strings.Builder
+	atSpace := false
+	for _, r := range rendered {
+		if atSpace {
+			r = strings.TrimLeft(r, " ")
+		}
+		if len(r) == 0 {
+			continue
+		}
+		content.WriteString(r)
+		atSpace = strings.HasSuffix(r, " ") || strings.HasSuffix(r, "\n")
+	}
+
+	return content.String()
+}
+
+// RenderInlineText renders the inline content like RenderInlines, but without any surrounding whitespace.
+// Line breaks at the start or end of the content are left out since they have no effect.
+func RenderInlineText(inlines []Inline, ctx EscapeContext) string {
+	for len(inlines) > 0 && IsBlankInline(inlines[0]) {
+		inlines = inlines[1:]
+	}
+	for len(inlines) > 0 && IsBlankInline(inlines[len(inlines)-1]) {
+		inlines = inlines[:len(inlines)-1]
+	}
+	return strings.TrimSpace(RenderInlines(inlines, ctx))
+}
+
+// IsBlankInline checks whether the inline is only whitespace or a line break
+func IsBlankInline(inline Inline) bool {
+	switch i := inline.(type) {
+	case LineBreak:
+		return true
+	case Text:
+		return strings.TrimSpace(i.Content) == ""
+	}
+	return false
+}
+
+func (t Text) renderInline(ctx EscapeContext) string {
+	return EscapeInline(t.Content)
+}
+
+func (em Emphasis) renderInline(ctx EscapeContext) string {
+	return wrapInline(RenderInlines(em.Children, ctx), "_", "_")
+}
+
+func (s Strong) renderInline(ctx EscapeContext) string {
+	return wrapInline(RenderInlines(s.Children, ctx), "**", "**")
+}
+
+func (s Strikethrough) renderInline(ctx EscapeContext) string {
+	return wrapInline(RenderInlines(s.Children, ctx), "~~", "~~")
+}
+
+func (c Code) renderInline(ctx EscapeContext) string {
+	if len(c.Content) == 0 {
+		return ""
+	}
+	return InlineCode(c.Content)
+}
+
+// renderInline renders the link. A link cannot contain another link, so only the
+// text is rendered if the link is within link text. Without a URL, there is only text.
+func (l Link) renderInline(ctx EscapeContext) string {
+	text := RenderInlines(l.Children, LinkTextContext)
+	if ctx == LinkTextContext || len(l.URL) == 0 {
+		return text
+	}
+
+	destination := Escape(l.URL, LinkURLContext)
+	if len(l.Title) > 0 {
+		destination += " " + quoteTitle(l.Title)
+	}
+	return wrapInline(text, "[", "]("+destination+")")
+}
+
+func (img Image) renderInline(ctx EscapeContext) string {
+	destination := Escape(img.Src, LinkURLContext)
+	if len(img.Title) > 0 {
+		destination += " " + quoteTitle(img.Title)
+	}
+	return "![" + Escape(img.Alt, LinkTextContext) + "](" + destination + ")"
+}
+
+// renderInline renders the line break. Tables and headers must be on a single line,
+// so the break is rendered as HTML in a table cell and as a space in a header.
+func (br LineBreak) renderInline(ctx EscapeContext) string {
+	switch ctx {
+	case TableCellContext:
+		return "<br>"
+	case HeadingContext, LinkTextContext:
+		return " "
+	}
+	return "\\\n"
+}
+
+func (raw RawInline) renderInline(ctx EscapeContext) string {
+	return raw.Content
+}
+
+// String renders the text with markdown characters escaped
+func (t Text) String() string { return t.renderInline(ParagraphContext) }
+
+// String renders the content wrapped in "_"
+func (em Emphasis) String() string { return em.renderInline(ParagraphContext) }
+
+// String renders the content wrapped in "**"
+func (s Strong) String() string { return s.renderInline(ParagraphContext) }
+
+// String renders the content wrapped in "~~"
+func (s Strikethrough) String() string { return s.renderInline(ParagraphContext) }
+
+// String renders the code wrapped in "`"
+func (c Code) String() string { return c.renderInline(ParagraphContext) }
+
+// String renders the link as [text](url "title")
+func (l Link) String() string { return l.renderInline(ParagraphContext) }
+
+// String renders the image as ![alt](src "title")
+func (img Image) String() string { return img.renderInline(ParagraphContext) }
+
+// String renders the line break as a "\" at the end of the line
+func (br LineBreak) String() string { return br.renderInline(ParagraphContext) }
+
+// String renders the content as is
+func (raw RawInline) String() string { return raw.renderInline(ParagraphContext) }
+
+// wrapInline wraps the content with the opening and closing markers.
+// Markers must be next to the content, so any whitespace at the start or end
+// of the content is moved outside of the markers. If there is no content
+// other than whitespace, then the markers are left out.
+func wrapInline(content string, open string, close string) string {
+	trimmed := strings.TrimSpace(content)
+	if len(trimmed) == 0 {
+		if len(content) > 0 {
+			return " "
+		}
+		return ""
+	}
+
+	var leading, trailing string
+	if trimmed[0] != content[0] {
+		leading = " "
+	}
+	if trimmed[len(trimmed)-1] != content[len(content)-1] {
+		trailing = " "
+	}
+	return leading + open + trimmed + close + trailing
+}
+
+// touchesWord reports whether the rendered inline at the index is directly
+// next to a letter or digit in the inline content before or after it.
+func touchesWord(rendered []string, idx int) bool {
+	current := rendered[idx]
+	if len(current) == 0 {
+		return false
+	}
+
+	if first, _ := utf8.DecodeRuneInString(current); !unicode.IsSpace(first) {
+		for prev := idx - 1; prev >= 0; prev-- {
+			if len(rendered[prev]) > 0 {
+				last, _ := utf8.DecodeLastRuneInString(rendered[prev])
+				if isWordRune(last) {
+					return true
+				}
+				break
+			}
+		}
+	}
+	if last, _ := utf8.DecodeLastRuneInString(current); !unicode.IsSpace(last) {
+		for next := idx + 1; next < len(rendered); next++ {
+			if len(rendered[next]) > 0 {
+				first, _ := utf8.DecodeRuneInString(rendered[next])
+				return isWordRune(first)
+			}
+		}
+	}
+	return false
+}
+
+// quoteTitle quotes the title of a link or image
+func quoteTitle(title string) string {
+	return `"` + strings.ReplaceAll(EscapeInline(title), `"`, `\"`) + `"`
+}
@@ -0,0 +1,104 @@
+package markdown
+
+import "testing"
+
+func TestRenderNestedInlines(t *testing.T) {
+	inlines := []Inline{
+		Text{Content: "See "},
+		Link{URL: "mock://example.com", Children: []Inline{Strong{Children: []Inline{Text{Content: "the *docs*"}}}}},
+		Text{Content: " and "},
+		Strong{Children: []Inline{Text{Content: "a "}, Emphasis{Children: []Inline{Text{Content: "b"}}}}},
+	}
+
+	result := RenderInlines(inlines, ParagraphContext)
+	expected := `See [**the \*docs\***](mock://example.com) and **a _b_**`
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestRenderInlinesMovesWhitespaceOutsideEmphasis(t *testing.T) {
+	inlines := []Inline{
+		Text{Content: "This is"},
+		Strong{Children: []Inline{Text{Content: " bold "}}},
+		Text{Content: "text"},
+		Emphasis{Children: []Inline{Text{Content: " "}}},
+		Text{Content: " and more"},
+	}
+
+	result := RenderInlines(inlines, ParagraphContext)
+	expected := "This is **bold** text and more"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestRenderInlinesEmphasisWithinWord(t *testing.T) {
+	inlines := []Inline{
+		Text{Content: "un"},
+		Emphasis{Children: []Inline{Text{Content: "believ"}}},
+		Text{Content: "able, "},
+		Emphasis{Children: []Inline{Text{Content: "truly"}}},
+	}
+
+	result := RenderInlines(inlines, ParagraphContext)
+	expected := "un*believ*able, _truly_"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestRenderLineBreak(t *testing.T) {
+	inlines := []Inline{Text{Content: "line 1"}, LineBreak{}, Text{Content: "line 2"}}
+
+	cases := map[EscapeContext]string{
+		ParagraphContext: "line 1\\\nline 2",
+		TableCellContext: "line 1<br>line 2",
+		HeadingContext:   "line 1 line 2",
+	}
+	for ctx, expected := range cases {
+		result := RenderInlines(inlines, ctx)
+		if result != expected {
+			t.Errorf("Expected %s. Got %s", expected, result)
+		}
+	}
+}
+
+func TestRenderLinkAndImage(t *testing.T) {
+	inlines := []Inline{
+		Link{URL: "mock://example.com/a b", Title: `A "title"`, Children: []Inline{
+			Image{Src: "mock://example.com/img.png", Alt: "[alt]"},
+		}},
+		Code{Content: "a`b"},
+	}
+
+	result := RenderInlines(inlines, ParagraphContext)
+	expected := "[![\\[alt\\]](mock://example.com/img.png)](mock://example.com/a%20b \"A \\\"title\\\"\")``a`b``"
+
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestIsBlankInline(t *testing.T) {
+	tests := map[string]struct {
+		inline   Inline
+		expected bool
+	}{
+		"whitespace": {Text{Content: " \n"}, true},
+		"line break": {LineBreak{}, true},
+		"text":       {Text{Content: " a "}, false},
+		"empty code": {Code{}, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := IsBlankInline(test.inline); result != test.expected {
+				t.Errorf("Expected %t. Got %t", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,491 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// jsonNode is a block or inline node of a document as JSON. Type is the kind of node,
+// and only the fields of that kind are set. Content is the inline content of the node,
+// while Markdown is content that is already rendered, for nodes that were not built from inlines.
+// Each cell of the Headers and Rows of a table is a list of inline nodes.
+type jsonNode struct {
+	Type       string         `json:"type"`
+	Position   *Position      `json:"position,omitempty"`
+	Level      int            `json:"level,omitempty"`
+	Ordered    bool           `json:"ordered,omitempty"`
+	Text       string         `json:"text,omitempty"`
+	Markdown   string         `json:"markdown,omitempty"`
+	Lang       string         `json:"lang,omitempty"`
+	Code       string         `json:"code,omitempty"`
+	HTML       string         `json:"html,omitempty"`
+	URL        string         `json:"url,omitempty"`
+	Src        string         `json:"src,omitempty"`
+	Alt        string         `json:"alt,omitempty"`
+	Title      string         `json:"title,omitempty"`
+	Kind       string         `json:"kind,omitempty"`
+	Collapse   bool           `json:"collapsible,omitempty"`
+	Headers    [][]jsonNode   `json:"headers,omitempty"`
+	Rows       [][][]jsonNode `json:"rows,omitempty"`
+	Alignments []Alignment    `json:"alignments,omitempty"`
+	Separator  *string        `json:"separator,omitempty"`
+	Content    []jsonNode     `json:"content,omitempty"`
+	Items      []jsonNode     `json:"items,omitempty"`
+	Blocks     []jsonNode     `json:"blocks,omitempty"`
+}
+
+// jsonDoc is a document as JSON
+type jsonDoc struct {
+	Title             *string           `json:"title,omitempty"`
+	TitleHeading      *bool             `json:"titleHeading,omitempty"`
+	Separator         *string           `json:"separator,omitempty"`
+	FrontMatterFormat FrontMatterFormat `json:"frontMatterFormat,omitempty"`
+	FrontMatter       *FrontMatter      `json:"frontMatter,omitempty"`
+	Blocks            []jsonNode        `json:"blocks"`
+}
+
+const (
+	jsonNestedDoc    = "doc"
+	jsonHeading      = "heading"
+	jsonParagraph    = "paragraph"
+	jsonList         = "list"
+	jsonItem         = "item"
+	jsonBlockquote   = "blockquote"
+	jsonCodeBlock    = "codeBlock"
+	jsonAdmonition   = "admonition"
+	jsonRule         = "rule"
+	jsonTable        = "table"
+	jsonHTML         = "html"
+	jsonRaw          = "raw"
+	jsonText         = "text"
+	jsonEmphasis     = "emphasis"
+	jsonStrong       = "strong"
+	jsonStrike       = "strikethrough"
+	jsonCode         = "code"
+	jsonLink         = "link"
+	jsonImage        = "image"
+	jsonLineBreak    = "break"
+	jsonAlignDefault = "default"
+)
+
+// MarshalJSON encodes the document as a tree of its blocks and inline content,
+// along with its title, front matter, and the source position of each block.
+// RawBlocks are encoded as "raw" blocks of their markdown.
+func (d *Doc) MarshalJSON() ([]byte, error) {
+	doc := jsonDoc{
+		Title:        d.title,
+		TitleHeading: d.titleHeading,
+		Blocks:       d.jsonBlocks(),
+	}
+	if d.getSeparator() != defaultSeparator {
+		doc.Separator = d.separator
+	}
+	if d.frontMatter != nil && d.frontMatter.Len() > 0 {
+		doc.FrontMatterFormat = d.frontMatter.Format
+		doc.FrontMatter = d.frontMatter
+	}
+	return marshalJSON(doc)
+}
+
+// marshalJSON encodes the value without escaping HTML characters, which are common in markdown
+func marshalJSON(value interface{}) ([]byte, error) {
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(content.Bytes(), []byte("\n")), nil
+}
+
+// UnmarshalJSON decodes a document encoded by MarshalJSON
+func (d *Doc) UnmarshalJSON(data []byte) error {
+	var doc jsonDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	*d = *NewDoc(DocConfig{Title: doc.Title, TitleHeading: doc.TitleHeading, Separator: doc.Separator})
+	if doc.FrontMatter != nil {
+		doc.FrontMatter.Format = doc.FrontMatterFormat
+		d.frontMatter = doc.FrontMatter
+	}
+	return d.addJSONBlocks(doc.Blocks)
+}
+
+func (d *Doc) jsonBlocks() []jsonNode {
+	blocks := make([]jsonNode, len(d.content))
+	for idx, block := range d.content {
+		blocks[idx] = toJSONBlock(block)
+		if pos := d.Position(idx); pos != (Position{}) {
+			blocks[idx].Position = &pos
+		}
+	}
+	return blocks
+}
+
+func (d *Doc) addJSONBlocks(nodes []jsonNode) error {
+	for _, node := range nodes {
+		block, err := fromJSONBlock(node)
+		if err != nil {
+			return err
+		}
+		d.AddContent(block)
+		if node.Position != nil {
+			d.positions[len(d.positions)-1] = *node.Position
+		}
+	}
+	return nil
+}
+
+func toJSONBlock(block Block) jsonNode {
+	switch b := block.(type) {
+	case *Doc:
+		node := jsonNode{Type: jsonNestedDoc, Blocks: b.jsonBlocks()}
+		if b.getSeparator() != defaultSeparator {
+			node.Separator = b.separator
+		}
+		return node
+	case Header:
+		node := jsonNode{Type: jsonHeading, Level: b.Level()}
+		node.Content, node.Markdown = toJSONText(b.Inlines, b.Content)
+		return node
+	case Paragraph:
+		node := jsonNode{Type: jsonParagraph}
+		node.Content, node.Markdown = toJSONText(b.Inlines, b.Content)
+		return node
+	case List:
+		node := jsonNode{Type: jsonList, Ordered: b.Ordered(), Items: make([]jsonNode, len(b.Items))}
+		for idx, item := range b.Items {
+			node.Items[idx] = toJSONItem(item)
+		}
+		return node
+	case Blockquote:
+		node := jsonNode{Type: jsonBlockquote}
+		if b.Content != nil {
+			node.Blocks = []jsonNode{toJSONBlock(b.Content)}
+		}
+		return node
+	case CodeBlock:
+		return jsonNode{Type: jsonCodeBlock, Lang: b.Lang, Title: b.Title, Code: b.Code}
+	case Admonition:
+		node := jsonNode{Type: jsonAdmonition, Kind: b.Kind, Title: b.Title, Collapse: b.Collapsible}
+		if b.Content != nil {
+			node.Blocks = b.Content.jsonBlocks()
+		}
+		return node
+	case HorizontalRule:
+		return jsonNode{Type: jsonRule}
+	case Table:
+		node := jsonNode{Type: jsonTable, Headers: toJSONCells(b.Headers), Alignments: b.Alignments}
+		for _, row := range b.Rows {
+			node.Rows = append(node.Rows, toJSONCells(row))
+		}
+		return node
+	case RawHTML:
+		return jsonNode{Type: jsonHTML, HTML: b.Content}
+	}
+	return jsonNode{Type: jsonRaw, Markdown: block.String()}
+}
+
+// toJSONCells encodes the cells of a row of a table as their inline content
+func toJSONCells(cells []TableCell) [][]jsonNode {
+	if cells == nil {
+		return nil
+	}
+	nodes := make([][]jsonNode, len(cells))
+	for idx, cell := range cells {
+		nodes[idx] = toJSONInlines(cell)
+	}
+	return nodes
+}
+
+func toJSONItem(item ListItem) jsonNode {
+	node := jsonNode{Type: jsonItem}
+	node.Content, node.Markdown = toJSONText(item.Inlines, item.Content)
+	for _, block := range item.Blocks {
+		node.Blocks = append(node.Blocks, toJSONBlock(block))
+	}
+	return node
+}
+
+// toJSONText encodes the text of a block as its inline content, or its markdown if it has no inlines
+func toJSONText(inlines []Inline, content string) ([]jsonNode, string) {
+	if inlines == nil {
+		return nil, content
+	}
+	return toJSONInlines(inlines), ""
+}
+
+func toJSONInlines(inlines []Inline) []jsonNode {
+	nodes := make([]jsonNode, len(inlines))
+	for idx, inline := range inlines {
+		nodes[idx] = toJSONInline(inline)
+	}
+	return nodes
+}
+
+func toJSONInline(inline Inline) jsonNode {
+	switch i := inline.(type) {
+	case Text:
+		return jsonNode{Type: jsonText, Text: i.Content}
+	case Emphasis:
+		return jsonNode{Type: jsonEmphasis, Content: toJSONInlines(i.Children)}
+	case Strong:
+		return jsonNode{Type: jsonStrong, Content: toJSONInlines(i.Children)}
+	case Strikethrough:
+		return jsonNode{Type: jsonStrike, Content: toJSONInlines(i.Children)}
+	case Code:
+		return jsonNode{Type: jsonCode, Code: i.Content}
+	case Link:
+		return jsonNode{Type: jsonLink, URL: i.URL, Title: i.Title, Content: toJSONInlines(i.Children)}
+	case Image:
+		return jsonNode{Type: jsonImage, Src: i.Src, Alt: i.Alt, Title: i.Title}
+	case LineBreak:
+		return jsonNode{Type: jsonLineBreak}
+	}
+	return jsonNode{Type: jsonRaw, Markdown: inline.String()}
+}
+
+func fromJSONBlock(node jsonNode) (Block, error) {
+	switch node.Type {
+	case jsonNestedDoc:
+		doc := NewDoc(DocConfig{Separator: node.Separator})
+		return doc, doc.addJSONBlocks(node.Blocks)
+	case jsonHeading:
+		inlines, err := fromJSONInlines(node.Content)
+		if err != nil {
+			return nil, err
+		}
+		header := NewHeader(node.Level, node.Markdown)
+		header.Inlines = inlines
+		return header, nil
+	case jsonParagraph:
+		inlines, err := fromJSONInlines(node.Content)
+		return Paragraph{Content: node.Markdown, Inlines: inlines}, err
+	case jsonList:
+		items := make([]ListItem, len(node.Items))
+		for idx, itemNode := range node.Items {
+			item, err := fromJSONItem(itemNode)
+			if err != nil {
+				return nil, err
+			}
+			items[idx] = item
+		}
+		if node.Ordered {
+			return NewOrderedListFromItems(items), nil
+		}
+		return NewUnorderedListFromItems(items), nil
+	case jsonBlockquote:
+		quote := NewDoc(DocConfig{})
+		if err := quote.addJSONBlocks(node.Blocks); err != nil {
+			return nil, err
+		}
+		// A blockquote of a single document is the document itself
+		if len(quote.content) == 1 {
+			return Blockquote{Content: quote.content[0]}, nil
+		}
+		return Blockquote{Content: quote}, nil
+	case jsonCodeBlock:
+		return CodeBlock{Lang: node.Lang, Title: node.Title, Code: node.Code}, nil
+	case jsonAdmonition:
+		content := NewDoc(DocConfig{})
+		err := content.addJSONBlocks(node.Blocks)
+		return Admonition{Kind: node.Kind, Title: node.Title, Collapsible: node.Collapse, Content: content}, err
+	case jsonRule:
+		return HorizontalRule{}, nil
+	case jsonTable:
+		headers, err := fromJSONCells(node.Headers)
+		if err != nil {
+			return nil, err
+		}
+		table := Table{Headers: headers, Alignments: node.Alignments}
+		for _, rowNodes := range node.Rows {
+			row, err := fromJSONCells(rowNodes)
+			if err != nil {
+				return nil, err
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		return table, nil
+	case jsonHTML:
+		return RawHTML{Content: node.HTML}, nil
+	case jsonRaw:
+		return RawBlock{Content: node.Markdown}, nil
+	}
+	return nil, fmt.Errorf("unknown markdown block type %q", node.Type)
+}
+
+func fromJSONItem(node jsonNode) (ListItem, error) {
+	inlines, err := fromJSONInlines(node.Content)
+	if err != nil {
+		return ListItem{}, err
+	}
+	item := ListItem{Content: node.Markdown, Inlines: inlines}
+	for _, blockNode := range node.Blocks {
+		block, err := fromJSONBlock(blockNode)
+		if err != nil {
+			return ListItem{}, err
+		}
+		item.Blocks = append(item.Blocks, block)
+	}
+	return item, nil
+}
+
+// fromJSONCells decodes the cells of a row of a table from their inline content
+func fromJSONCells(nodes [][]jsonNode) ([]TableCell, error) {
+	if nodes == nil {
+		return nil, nil
+	}
+	cells := make([]TableCell, len(nodes))
+	for idx, cellNodes := range nodes {
+		inlines, err := fromJSONInlines(cellNodes)
+		if err != nil {
+			return nil, err
+		}
+		cells[idx] = inlines
+	}
+	return cells, nil
+}
+
+func fromJSONInlines(nodes []jsonNode) ([]Inline, error) {
+	if nodes == nil {
+		return nil, nil
+	}
+	inlines := make([]Inline, len(nodes))
+	for idx, node := range nodes {
+		inline, err := fromJSONInline(node)
+		if err != nil {
+			return nil, err
+		}
+		inlines[idx] = inline
+	}
+	return inlines, nil
+}
+
+func fromJSONInline(node jsonNode) (Inline, error) {
+	var children []Inline
+	if node.Content != nil {
+		var err error
+		if children, err = fromJSONInlines(node.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	switch node.Type {
+	case jsonText:
+		return Text{Content: node.Text}, nil
+	case jsonEmphasis:
+		return Emphasis{Children: children}, nil
+	case jsonStrong:
+		return Strong{Children: children}, nil
+	case jsonStrike:
+		return Strikethrough{Children: children}, nil
+	case jsonCode:
+		return Code{Content: node.Code}, nil
+	case jsonLink:
+		return Link{URL: node.URL, Title: node.Title, Children: children}, nil
+	case jsonImage:
+		return Image{Src: node.Src, Alt: node.Alt, Title: node.Title}, nil
+	case jsonLineBreak:
+		return LineBreak{}, nil
+	case jsonRaw:
+		return RawInline{Content: node.Markdown}, nil
+	}
+	return nil, fmt.Errorf("unknown markdown node type %q", node.Type)
+}
+
+// MarshalJSON encodes the alignment as "left", "center", "right", or "default"
+func (a Alignment) MarshalJSON() ([]byte, error) {
+	names := map[Alignment]string{AlignLeft: "left", AlignCenter: "center", AlignRight: "right"}
+	name, exists := names[a]
+	if !exists {
+		name = jsonAlignDefault
+	}
+	return json.Marshal(name)
+}
+
+// UnmarshalJSON decodes an alignment encoded by MarshalJSON
+func (a *Alignment) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	switch name {
+	case "left":
+		*a = AlignLeft
+	case "center":
+		*a = AlignCenter
+	case "right":
+		*a = AlignRight
+	case jsonAlignDefault, "":
+		*a = AlignDefault
+	default:
+		return fmt.Errorf("unknown alignment %q", name)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the front matter as a JSON object, with the keys in the order they were set.
+// The format of the front matter is not encoded.
+func (fm *FrontMatter) MarshalJSON() ([]byte, error) {
+	if fm == nil || len(fm.keys) == 0 {
+		return []byte("{}"), nil
+	}
+	return []byte(fm.json()), nil
+}
+
+// UnmarshalJSON decodes a JSON object into the front matter, keeping the order of the keys.
+// Whole numbers are decoded as ints, and lists of strings as []string.
+func (fm *FrontMatter) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil {
+		return err
+	} else if token != json.Delim('{') {
+		return fmt.Errorf("front matter must be a JSON object")
+	}
+
+	fm.keys = nil
+	fm.values = map[string]interface{}{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		fm.Set(key, frontMatterValue(value))
+	}
+	_, err := decoder.Token()
+	return err
+}
+
+// frontMatterValue converts a decoded JSON value to a value of the front matter
+func frontMatterValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil && i >= math.MinInt && i <= math.MaxInt {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		items := make([]string, len(v))
+		for idx, item := range v {
+			s, isString := item.(string)
+			if !isString {
+				return value
+			}
+			items[idx] = s
+		}
+		return items
+	}
+	return value
+}
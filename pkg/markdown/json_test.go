@@ -0,0 +1,158 @@
+package markdown
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/util"
+)
+
+func newJSONTestDoc() *Doc {
+	frontMatter := NewFrontMatter(FrontMatterYAML)
+	frontMatter.Set("title", "Test Doc")
+	frontMatter.Set("weight", 3)
+	frontMatter.Set("tags", []string{"a", "b"})
+	frontMatter.Set("draft", false)
+
+	doc := NewDoc(DocConfig{Title: util.String("Test Doc"), FrontMatter: frontMatter})
+	doc.AddHeaderInlines("h1", []Inline{Text{Content: "Section "}, Code{Content: "x"}})
+	doc.SetPositions(0, Position{Line: 4, Path: "html > body > h1"})
+	doc.AddParagraphInlines([]Inline{
+		Strong{Children: []Inline{Text{Content: "Bold"}}},
+		Text{Content: " and "},
+		Link{URL: "/docs", Title: "Docs", Children: []Inline{Emphasis{Children: []Inline{Text{Content: "docs"}}}}},
+		LineBreak{},
+		Image{Src: "a.png", Alt: "A"},
+		RawInline{Content: "<kbd>K</kbd>"},
+		Strikethrough{Children: []Inline{Text{Content: "old"}}},
+	})
+	doc.AddParagraph("Already *markdown*")
+	doc.AddContent(NewOrderedListFromItems([]ListItem{
+		{Inlines: []Inline{Text{Content: "One"}}, Blocks: []Block{NewUnorderedList([]string{"Nested"})}},
+		{Content: "Two"},
+	}))
+	quote := NewDoc(doc.GetRenderConfig())
+	quote.AddParagraph("Quote")
+	doc.AddBlockquote(quote)
+	doc.AddCodeBlock("go", "fmt.Println()")
+	doc.AddContent(CodeBlock{Lang: "sh", Title: "build.sh", Code: "go build"})
+	panel := NewDoc(doc.GetRenderConfig())
+	panel.AddParagraph("Panel")
+	doc.AddContent(Admonition{Kind: "tip", Title: "Tip", Collapsible: true, Content: panel})
+	doc.AddHorizontalRule()
+	table := NewTable([]string{"A", "B"}, [][]string{{"1", "2"}})
+	table.Alignments = []Alignment{AlignCenter}
+	doc.AddContent(table)
+	doc.AddRawHTML("<dl><dt>Term</dt></dl>")
+	doc.AddDoc(NewDoc(DocConfig{Separator: util.String("\n")}))
+	return doc
+}
+
+func TestDocJSONRoundTrip(t *testing.T) {
+	doc := newJSONTestDoc()
+
+	content, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	decoded := &Doc{}
+	if err := json.Unmarshal(content, decoded); err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	if decoded.String() != doc.String() {
+		t.Errorf("Expected\n%s\nGot\n%s", doc.String(), decoded.String())
+	}
+
+	reencoded, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	if string(reencoded) != string(content) {
+		t.Errorf("Expected\n%s\nGot\n%s", content, reencoded)
+	}
+
+	if pos := decoded.Position(0); pos.Line != 4 || pos.Path != "html > body > h1" {
+		t.Errorf("Expected the position of the header. Got %v", pos)
+	}
+}
+
+func TestDocJSON(t *testing.T) {
+	doc := NewDoc(DocConfig{ReduceHeaders: util.Bool(false)})
+	doc.AddHeaderInlines("h2", []Inline{Text{Content: "Title"}})
+	doc.AddContent(NewUnorderedListFromItems([]ListItem{{Inlines: []Inline{Link{URL: "/a", Children: []Inline{Text{Content: "A"}}}}}}))
+
+	content, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := `{"titleHeading":true,"blocks":[` +
+		`{"type":"heading","level":2,"content":[{"type":"text","text":"Title"}]},` +
+		`{"type":"list","items":[{"type":"item","content":[{"type":"link","url":"/a","content":[{"type":"text","text":"A"}]}]}]}]}`
+	if string(content) != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, content)
+	}
+}
+
+func TestTableJSON(t *testing.T) {
+	doc := NewDoc(DocConfig{})
+	doc.AddContent(Table{
+		Headers: []TableCell{{Text{Content: "Page"}}, nil},
+		Rows:    [][]TableCell{{{Link{URL: "/docs", Children: []Inline{Text{Content: "Docs"}}}}, {Code{Content: "a|b"}}}},
+	})
+
+	content, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	expected := `{"titleHeading":true,"blocks":[{"type":"table",` +
+		`"headers":[[{"type":"text","text":"Page"}],[]],` +
+		`"rows":[[[{"type":"link","url":"/docs","content":[{"type":"text","text":"Docs"}]}],[{"type":"code","code":"a|b"}]]]}]}`
+	if string(content) != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, content)
+	}
+
+	decoded := &Doc{}
+	if err := json.Unmarshal(content, decoded); err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+	if decoded.String() != doc.String() {
+		t.Errorf("Expected\n%s\nGot\n%s", doc.String(), decoded.String())
+	}
+}
+
+func TestFrontMatterJSON(t *testing.T) {
+	frontMatter := NewFrontMatter(FrontMatterTOML)
+	frontMatter.Set("title", "Doc")
+	frontMatter.Set("date", time.Date(2020, 3, 3, 0, 0, 0, 0, time.UTC))
+	frontMatter.Set("rating", 4.5)
+
+	content, err := json.Marshal(frontMatter)
+	if err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	decoded := NewFrontMatter(FrontMatterTOML)
+	if err := json.Unmarshal(content, decoded); err != nil {
+		t.Fatalf("Expected no error. Got %s", err)
+	}
+
+	if keys := strings.Join(decoded.Keys(), ","); keys != "title,date,rating" {
+		t.Errorf("Expected title,date,rating. Got %s", keys)
+	}
+	if rating, _ := decoded.Get("rating"); rating != 4.5 {
+		t.Errorf("Expected 4.5. Got %v", rating)
+	}
+}
+
+func TestDocJSONUnknownType(t *testing.T) {
+	err := json.Unmarshal([]byte(`{"blocks":[{"type":"video"}]}`), &Doc{})
+	if err == nil || !strings.Contains(err.Error(), "video") {
+		t.Errorf("Expected an error for the unknown type. Got %v", err)
+	}
+}
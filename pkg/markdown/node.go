@@ -0,0 +1,64 @@
+package markdown
+
+import (
+	"fmt"
+)
+
+// Node is a block or inline node of a markdown document, such as a Doc, Paragraph, List,
+// ListItem, Link, or Text. Only the types of this package are nodes.
+type Node interface {
+	fmt.Stringer
+	isNode()
+}
+
+// Block is a node that is a block of a document, such as a Paragraph, List, Table, or another Doc.
+// Content that is already rendered, such as markdown for a flavor, can be added as a RawBlock.
+type Block interface {
+	Node
+	isBlock()
+}
+
+// RawBlock is a block that is already rendered, such as a component rendered
+// for a flavor of markdown. It is rendered as is.
+type RawBlock struct {
+	Content string
+}
+
+// String renders the content as is
+func (raw RawBlock) String() string {
+	return raw.Content
+}
+
+func (*Doc) isNode()           {}
+func (Header) isNode()         {}
+func (List) isNode()           {}
+func (ListItem) isNode()       {}
+func (Paragraph) isNode()      {}
+func (Blockquote) isNode()     {}
+func (CodeBlock) isNode()      {}
+func (Admonition) isNode()     {}
+func (HorizontalRule) isNode() {}
+func (Table) isNode()          {}
+func (RawHTML) isNode()        {}
+func (RawBlock) isNode()       {}
+func (Text) isNode()           {}
+func (Emphasis) isNode()       {}
+func (Strong) isNode()         {}
+func (Strikethrough) isNode()  {}
+func (Code) isNode()           {}
+func (Link) isNode()           {}
+func (Image) isNode()          {}
+func (LineBreak) isNode()      {}
+func (RawInline) isNode()      {}
+
+func (*Doc) isBlock()           {}
+func (Header) isBlock()         {}
+func (List) isBlock()           {}
+func (Paragraph) isBlock()      {}
+func (Blockquote) isBlock()     {}
+func (CodeBlock) isBlock()      {}
+func (Admonition) isBlock()     {}
+func (HorizontalRule) isBlock() {}
+func (Table) isBlock()          {}
+func (RawHTML) isBlock()        {}
+func (RawBlock) isBlock()       {}
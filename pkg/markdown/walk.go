@@ -0,0 +1,251 @@
+package markdown
+
+import (
+	"fmt"
+)
+
+// Visitor visits each node walked by Walk. If the Visitor w returned by Visit is not nil,
+// then the children of the node are walked with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk walks the node and its children in depth first order, such as the blocks of a Doc,
+// the items of a List, and the inline content of a Paragraph or of each cell of a Table.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	for _, child := range children(node) {
+		Walk(v, child)
+	}
+
+	v.Visit(nil)
+}
+
+// inspector is a Visitor for a function
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect walks the node and its children in depth first order, calling f for each node.
+// The children of a node are skipped if f returns false. After the children of a node,
+// f is called with nil.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// children are the nodes within the node, in the order they are rendered
+func children(node Node) []Node {
+	var nodes []Node
+	switch n := node.(type) {
+	case *Doc:
+		for _, block := range n.content {
+			nodes = append(nodes, block)
+		}
+	case Blockquote:
+		if n.Content != nil {
+			nodes = append(nodes, n.Content)
+		}
+	case Admonition:
+		if n.Content != nil {
+			nodes = append(nodes, n.Content)
+		}
+	case List:
+		for _, item := range n.Items {
+			nodes = append(nodes, item)
+		}
+	case ListItem:
+		nodes = appendInlines(nodes, n.Inlines)
+		for _, block := range n.Blocks {
+			nodes = append(nodes, block)
+		}
+	case Paragraph:
+		nodes = appendInlines(nodes, n.Inlines)
+	case Table:
+		for _, cell := range n.Headers {
+			nodes = appendInlines(nodes, cell)
+		}
+		for _, row := range n.Rows {
+			for _, cell := range row {
+				nodes = appendInlines(nodes, cell)
+			}
+		}
+	case Header:
+		nodes = appendInlines(nodes, n.Inlines)
+	case Emphasis:
+		nodes = appendInlines(nodes, n.Children)
+	case Strong:
+		nodes = appendInlines(nodes, n.Children)
+	case Strikethrough:
+		nodes = appendInlines(nodes, n.Children)
+	case Link:
+		nodes = appendInlines(nodes, n.Children)
+	}
+	return nodes
+}
+
+func appendInlines(nodes []Node, inlines []Inline) []Node {
+	for _, inline := range inlines {
+		nodes = append(nodes, inline)
+	}
+	return nodes
+}
+
+// Rewrite replaces the node and its children with the nodes returned by f.
+// The children of a node are rewritten before the node itself, so f sees the rewritten children.
+// If f returns nil, then the node is removed from its parent. Docs are changed in place,
+// while other nodes are values, so their rewritten copies are placed in their parents.
+// Rewrite panics if f replaces a block with a node that is not a Block, an inline with a node
+// that is not an Inline, an item of a List with a node that is not a ListItem, or the content
+// of an Admonition with a node that is not a Doc, since they cannot be placed in their parents.
+//
+// For example, to make every link absolute
+//
+//	markdown.Rewrite(doc, func(node markdown.Node) markdown.Node {
+//		if link, isLink := node.(markdown.Link); isLink && strings.HasPrefix(link.URL, "/") {
+//			link.URL = "https://example.com" + link.URL
+//			return link
+//		}
+//		return node
+//	})
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *Doc:
+		var content []Block
+		var positions []Position
+		for idx, block := range n.content {
+			if block = rewriteBlock(block, f); block != nil {
+				content = append(content, block)
+				positions = append(positions, n.Position(idx))
+			}
+		}
+		n.content, n.positions = content, positions
+	case Blockquote:
+		if n.Content != nil {
+			n.Content = rewriteBlock(n.Content, f)
+		}
+		node = n
+	case Admonition:
+		if n.Content != nil {
+			switch content := Rewrite(n.Content, f).(type) {
+			case nil:
+				n.Content = nil
+			case *Doc:
+				n.Content = content
+			default:
+				panic(fmt.Sprintf("markdown: the content of an admonition cannot be replaced with %T", content))
+			}
+		}
+		node = n
+	case List:
+		var items []ListItem
+		for _, item := range n.Items {
+			switch rewritten := Rewrite(item, f).(type) {
+			case nil:
+			case ListItem:
+				items = append(items, rewritten)
+			default:
+				panic(fmt.Sprintf("markdown: a list item cannot be replaced with %T", rewritten))
+			}
+		}
+		n.Items = items
+		node = n
+	case ListItem:
+		n.Inlines = rewriteInlines(n.Inlines, f)
+		n.Blocks = rewriteBlocks(n.Blocks, f)
+		node = n
+	case Paragraph:
+		n.Inlines = rewriteInlines(n.Inlines, f)
+		node = n
+	case Table:
+		n.Headers = rewriteCells(n.Headers, f)
+		if n.Rows != nil {
+			rows := make([][]TableCell, len(n.Rows))
+			for idx, row := range n.Rows {
+				rows[idx] = rewriteCells(row, f)
+			}
+			n.Rows = rows
+		}
+		node = n
+	case Header:
+		n.Inlines = rewriteInlines(n.Inlines, f)
+		node = n
+	case Emphasis:
+		n.Children = rewriteInlines(n.Children, f)
+		node = n
+	case Strong:
+		n.Children = rewriteInlines(n.Children, f)
+		node = n
+	case Strikethrough:
+		n.Children = rewriteInlines(n.Children, f)
+		node = n
+	case Link:
+		n.Children = rewriteInlines(n.Children, f)
+		node = n
+	}
+	return f(node)
+}
+
+// rewriteBlock rewrites the block, which is nil if it is removed
+func rewriteBlock(block Block, f func(Node) Node) Block {
+	switch node := Rewrite(block, f).(type) {
+	case nil:
+		return nil
+	case Block:
+		return node
+	default:
+		panic(fmt.Sprintf("markdown: a block cannot be replaced with %T", node))
+	}
+}
+
+// rewriteBlocks rewrites each block, leaving out the blocks that are removed
+func rewriteBlocks(blocks []Block, f func(Node) Node) []Block {
+	if blocks == nil {
+		return nil
+	}
+	rewritten := make([]Block, 0, len(blocks))
+	for _, block := range blocks {
+		if block = rewriteBlock(block, f); block != nil {
+			rewritten = append(rewritten, block)
+		}
+	}
+	return rewritten
+}
+
+// rewriteCells rewrites the inline content of each cell of a row. Cells are kept even if
+// all of their content is removed, so that the other cells stay in their columns.
+func rewriteCells(cells []TableCell, f func(Node) Node) []TableCell {
+	if cells == nil {
+		return nil
+	}
+	rewritten := make([]TableCell, len(cells))
+	for idx, cell := range cells {
+		rewritten[idx] = rewriteInlines(cell, f)
+	}
+	return rewritten
+}
+
+// rewriteInlines rewrites each inline, leaving out the inlines that are removed
+func rewriteInlines(inlines []Inline, f func(Node) Node) []Inline {
+	if inlines == nil {
+		return nil
+	}
+	rewritten := make([]Inline, 0, len(inlines))
+	for _, inline := range inlines {
+		switch node := Rewrite(inline, f).(type) {
+		case nil:
+		case Inline:
+			rewritten = append(rewritten, node)
+		default:
+			panic(fmt.Sprintf("markdown: an inline cannot be replaced with %T", node))
+		}
+	}
+	return rewritten
+}
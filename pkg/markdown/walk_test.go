@@ -0,0 +1,194 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/david-mk-lawrence/htmltomd/pkg/util"
+)
+
+func newWalkTestDoc() *Doc {
+	doc := NewDoc(DocConfig{ReduceHeaders: util.Bool(false)})
+	doc.AddHeaderInlines("h1", []Inline{Text{Content: "Title"}})
+	doc.AddParagraphInlines([]Inline{
+		Text{Content: "See "},
+		Link{URL: "/docs", Children: []Inline{Strong{Children: []Inline{Text{Content: "the docs"}}}}},
+		Text{Content: " and "},
+		Image{Src: "diagram.png", Alt: "Diagram"},
+	})
+	doc.AddContent(NewUnorderedListFromItems([]ListItem{
+		{Inlines: []Inline{Link{URL: "/install", Children: []Inline{Text{Content: "Install"}}}}},
+		{Content: "Configure"},
+	}))
+
+	quote := NewDoc(doc.GetRenderConfig())
+	quote.AddHeaderInlines("h2", []Inline{Text{Content: "Quoted"}})
+	doc.AddBlockquote(quote)
+	return doc
+}
+
+func TestInspect(t *testing.T) {
+	doc := newWalkTestDoc()
+
+	var urls []string
+	Inspect(doc, func(node Node) bool {
+		switch n := node.(type) {
+		case Link:
+			urls = append(urls, n.URL)
+		case Image:
+			urls = append(urls, n.Src)
+		}
+		return true
+	})
+
+	result := strings.Join(urls, ",")
+	expected := "/docs,diagram.png,/install"
+	if result != expected {
+		t.Errorf("Expected %s. Got %s", expected, result)
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	doc := newWalkTestDoc()
+
+	texts := 0
+	Inspect(doc, func(node Node) bool {
+		if _, isText := node.(Text); isText {
+			texts++
+		}
+		_, isList := node.(List)
+		return !isList
+	})
+
+	if texts != 5 {
+		t.Errorf("Expected 5. Got %d", texts)
+	}
+}
+
+// depthVisitor records the deepest level of nesting
+type depthVisitor struct {
+	depth    int
+	maxDepth *int
+}
+
+func (v depthVisitor) Visit(node Node) Visitor {
+	if node == nil {
+		return nil
+	}
+	*v.maxDepth = max(*v.maxDepth, v.depth)
+	return depthVisitor{depth: v.depth + 1, maxDepth: v.maxDepth}
+}
+
+func TestWalk(t *testing.T) {
+	maxDepth := 0
+	Walk(depthVisitor{maxDepth: &maxDepth}, newWalkTestDoc())
+
+	// Doc > Paragraph > Link > Strong > Text
+	if maxDepth != 4 {
+		t.Errorf("Expected 4. Got %d", maxDepth)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	doc := newWalkTestDoc()
+
+	Rewrite(doc, func(node Node) Node {
+		switch n := node.(type) {
+		case Header:
+			return n.WithLevel(n.Level() + 1)
+		case Link:
+			n.URL = "https://example.com" + n.URL
+			return n
+		case Image:
+			return nil
+		case ListItem:
+			if n.Text() == "Configure" {
+				return nil
+			}
+		}
+		return node
+	})
+
+	result := doc.String()
+	expected := `## Title
+
+See [**the docs**](https://example.com/docs) and
+
+* [Install](https://example.com/install)
+
+> ### Quoted`
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestRewriteInvalidReplacement(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+
+	Rewrite(newWalkTestDoc(), func(node Node) Node {
+		if _, isText := node.(Text); isText {
+			return Paragraph{Content: "text"}
+		}
+		return node
+	})
+}
+
+func TestRewriteTableCells(t *testing.T) {
+	doc := NewDoc(DocConfig{})
+	doc.AddContent(Table{
+		Headers: []TableCell{{Text{Content: "Page"}}, {Text{Content: "Logo"}}},
+		Rows: [][]TableCell{
+			{{Link{URL: "/docs", Children: []Inline{Text{Content: "Docs"}}}}, {Image{Src: "logo.png", Alt: "Logo"}}},
+		},
+	})
+
+	var urls []string
+	Inspect(doc, func(node Node) bool {
+		switch n := node.(type) {
+		case Link:
+			urls = append(urls, n.URL)
+		case Image:
+			urls = append(urls, n.Src)
+		}
+		return true
+	})
+	if result := strings.Join(urls, ","); result != "/docs,logo.png" {
+		t.Errorf("Expected /docs,logo.png. Got %s", result)
+	}
+
+	Rewrite(doc, func(node Node) Node {
+		switch n := node.(type) {
+		case Link:
+			n.URL = "https://example.com" + n.URL
+			return n
+		case Image:
+			return nil
+		}
+		return node
+	})
+
+	result := doc.String()
+	expected := "| Page | Logo |\n| --- | --- |\n| [Docs](https://example.com/docs) |  |"
+	if result != expected {
+		t.Errorf("Expected\n%s\nGot\n%s", expected, result)
+	}
+}
+
+func TestRewriteInvalidBlockReplacement(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+
+	Rewrite(newWalkTestDoc(), func(node Node) Node {
+		if _, isHeader := node.(Header); isHeader {
+			return Text{Content: "text"}
+		}
+		return node
+	})
+}